@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadSaveDump loads a dump written by dump-json. Files ending in .yaml or .yml
+// are read as YAML with the same keys as the JSON dump.
+func ReadSaveDump(inputFilename string) (*SaveDump, error) {
+	inputData, err := os.ReadFile(inputFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(inputFilename))
+	if extension == ".yaml" || extension == ".yml" {
+		var yamlDocument interface{}
+		if err := yaml.Unmarshal(inputData, &yamlDocument); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", inputFilename, err)
+		}
+		inputData, err = json.Marshal(yamlDocument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %v to JSON: %w", inputFilename, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(inputData))
+	decoder.DisallowUnknownFields()
+	saveDump := &SaveDump{}
+	if err := decoder.Decode(saveDump); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", inputFilename, err)
+	}
+	return saveDump, nil
+}
+
+// ApplySaveDump copies every editable field from the dump into the save and returns a
+// description of each change. The dump must describe the same map with the same number
+// of records, and derived fields (Row, Col, Owner, Generals) must agree with the fields
+// they are derived from.
+func ApplySaveDump(saveOutput *fileio.WC4SaveOutput, saveDump *SaveDump) ([]string, error) {
+	if err := validateSaveDump(saveOutput, saveDump); err != nil {
+		return nil, err
+	}
+
+	changes := make([]string, 0)
+	changes = append(changes, diffStructFields("Header", saveOutput.SaveHeader, saveDump.Header)...)
+	saveOutput.SaveHeader = saveDump.Header
+
+	for i, player := range saveDump.Players {
+		changes = append(changes, diffStructFields(fmt.Sprintf("Player %v", i), saveOutput.PlayerData[i], player.CountryData)...)
+		saveOutput.PlayerData[i] = player.CountryData
+	}
+
+	for i := range saveDump.CityTiles {
+		for j := range saveDump.CityTiles[i] {
+			if saveOutput.CityTiles[i][j] != saveDump.CityTiles[i][j] {
+				changes = append(changes, fmt.Sprintf("City tile (%v, %v): %v -> %v", i, j, saveOutput.CityTiles[i][j], saveDump.CityTiles[i][j]))
+				saveOutput.CityTiles[i][j] = saveDump.CityTiles[i][j]
+			}
+		}
+	}
+
+	for i := range saveDump.UnitOwners {
+		for j := range saveDump.UnitOwners[i] {
+			newOwner := byte(saveDump.UnitOwners[i][j])
+			if saveOutput.UnitOwnerData[i][j] != newOwner {
+				changes = append(changes, fmt.Sprintf("Unit owner (%v, %v): %v -> %v", i, j, saveOutput.UnitOwnerData[i][j], newOwner))
+				saveOutput.UnitOwnerData[i][j] = newOwner
+			}
+		}
+	}
+
+	for i, city := range saveDump.Cities {
+		changes = append(changes, diffStructFields(fmt.Sprintf("City %v", i), saveOutput.Cities[i], city.CityData)...)
+		saveOutput.Cities[i] = city.CityData
+	}
+
+	for i, unit := range saveDump.Units {
+		changes = append(changes, diffStructFields(fmt.Sprintf("Unit %v", i), saveOutput.Units[i], unit.UnitData)...)
+		saveOutput.Units[i] = unit.UnitData
+	}
+
+	for i, landmine := range saveDump.Landmines {
+		changes = append(changes, diffStructFields(fmt.Sprintf("Landmine %v", i), saveOutput.Landmines[i], landmine.LandmineData)...)
+		saveOutput.Landmines[i] = landmine.LandmineData
+	}
+
+	return changes, nil
+}
+
+func validateSaveDump(saveOutput *fileio.WC4SaveOutput, saveDump *SaveDump) error {
+	oldHeader := reflect.ValueOf(saveOutput.SaveHeader)
+	newHeader := reflect.ValueOf(saveDump.Header)
+	for i := 0; i < oldHeader.NumField(); i++ {
+		fieldName := oldHeader.Type().Field(i).Name
+		isLayoutField := fieldName == "MapWidth" || fieldName == "MapHeight" || fieldName == "GameMode" ||
+			fieldName == "UnknownInt7" || fieldName == "UnknownInt10" ||
+			strings.HasSuffix(fieldName, "Count") || strings.HasPrefix(fieldName, "UnknownCount")
+		if isLayoutField && oldHeader.Field(i).Interface() != newHeader.Field(i).Interface() {
+			return fmt.Errorf("Header.%v changes the file layout and cannot be edited (%v -> %v)",
+				fieldName, oldHeader.Field(i).Interface(), newHeader.Field(i).Interface())
+		}
+	}
+
+	if len(saveDump.Players) != len(saveOutput.PlayerData) {
+		return fmt.Errorf("dump has %v players, save has %v", len(saveDump.Players), len(saveOutput.PlayerData))
+	}
+	for i, player := range saveDump.Players {
+		if player.Index != i {
+			return fmt.Errorf("player at position %v has index %v", i, player.Index)
+		}
+	}
+
+	if len(saveDump.CityTiles) != len(saveOutput.CityTiles) {
+		return fmt.Errorf("dump has %v city tile rows, save has %v", len(saveDump.CityTiles), len(saveOutput.CityTiles))
+	}
+	for i := range saveDump.CityTiles {
+		if len(saveDump.CityTiles[i]) != len(saveOutput.CityTiles[i]) {
+			return fmt.Errorf("city tile row %v has %v columns, save has %v", i, len(saveDump.CityTiles[i]), len(saveOutput.CityTiles[i]))
+		}
+	}
+
+	if len(saveDump.UnitOwners) != len(saveOutput.UnitOwnerData) {
+		return fmt.Errorf("dump has %v unit owner rows, save has %v", len(saveDump.UnitOwners), len(saveOutput.UnitOwnerData))
+	}
+	for i := range saveDump.UnitOwners {
+		if len(saveDump.UnitOwners[i]) != len(saveOutput.UnitOwnerData[i]) {
+			return fmt.Errorf("unit owner row %v has %v columns, save has %v", i, len(saveDump.UnitOwners[i]), len(saveOutput.UnitOwnerData[i]))
+		}
+		for j, owner := range saveDump.UnitOwners[i] {
+			if owner != 255 && (owner < 0 || owner >= len(saveOutput.PlayerData)) {
+				return fmt.Errorf("unit owner at (%v, %v) is %v, must be 255 or a player index below %v", i, j, owner, len(saveOutput.PlayerData))
+			}
+		}
+	}
+
+	// Row, Col and Owner are derived from the edited dump, not the original save
+	derivedSave := &fileio.WC4SaveOutput{UnitOwnerData: make([][]byte, len(saveDump.UnitOwners))}
+	for i, unitOwnerRow := range saveDump.UnitOwners {
+		derivedSave.UnitOwnerData[i] = make([]byte, len(unitOwnerRow))
+		for j, owner := range unitOwnerRow {
+			derivedSave.UnitOwnerData[i][j] = byte(owner)
+		}
+	}
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	checkDerivedLocation := func(recordName string, coordinateCode uint16, row int, col int, owner int, checkOwner bool) error {
+		expectedRow, expectedCol := fileio.ConvertCoordinates(int(coordinateCode), derivedSave.UnitOwnerData, gameMode)
+		if row != expectedRow || col != expectedCol {
+			return fmt.Errorf("%v is at (%v, %v) but CoordinateCode %v points to (%v, %v); edit CoordinateCode to move it",
+				recordName, row, col, coordinateCode, expectedRow, expectedCol)
+		}
+		if checkOwner && owner != fileio.GetTileOwner(derivedSave, row, col) {
+			return fmt.Errorf("%v has owner %v but UnitOwners has %v at (%v, %v); edit UnitOwners to change the owner",
+				recordName, owner, fileio.GetTileOwner(derivedSave, row, col), row, col)
+		}
+		return nil
+	}
+
+	if len(saveDump.Cities) != len(saveOutput.Cities) {
+		return fmt.Errorf("dump has %v cities, save has %v", len(saveDump.Cities), len(saveOutput.Cities))
+	}
+	for i, city := range saveDump.Cities {
+		if city.Index != i {
+			return fmt.Errorf("city at position %v has index %v", i, city.Index)
+		}
+		if err := checkDerivedLocation(fmt.Sprintf("City %v", i), city.CoordinateCode, city.Row, city.Col, city.Owner, true); err != nil {
+			return err
+		}
+	}
+
+	if len(saveDump.Units) != len(saveOutput.Units) {
+		return fmt.Errorf("dump has %v units, save has %v", len(saveDump.Units), len(saveOutput.Units))
+	}
+	for i, unit := range saveDump.Units {
+		if unit.Index != i {
+			return fmt.Errorf("unit at position %v has index %v", i, unit.Index)
+		}
+		if err := checkDerivedLocation(fmt.Sprintf("Unit %v", i), unit.CoordinateCode, unit.Row, unit.Col, unit.Owner, true); err != nil {
+			return err
+		}
+	}
+
+	for _, general := range saveDump.Generals {
+		if general.UnitIndex < 0 || general.UnitIndex >= len(saveDump.Units) {
+			return fmt.Errorf("general refers to unit %v which does not exist", general.UnitIndex)
+		}
+		unit := saveDump.Units[general.UnitIndex].UnitData
+		if general.GeneralId != unit.GeneralId || general.GeneralMilitaryRank != unit.GeneralMilitaryRank ||
+			general.GeneralTitle != unit.GeneralTitle || general.GeneralBadges != unit.GeneralBadges ||
+			general.GeneralSkillLevels != unit.GeneralSkillLevels {
+			return fmt.Errorf("general on unit %v does not match the unit record; edit generals through Units", general.UnitIndex)
+		}
+	}
+
+	if len(saveDump.Landmines) != len(saveOutput.Landmines) {
+		return fmt.Errorf("dump has %v landmines, save has %v", len(saveDump.Landmines), len(saveOutput.Landmines))
+	}
+	for i, landmine := range saveDump.Landmines {
+		if landmine.Index != i {
+			return fmt.Errorf("landmine at position %v has index %v", i, landmine.Index)
+		}
+		if err := checkDerivedLocation(fmt.Sprintf("Landmine %v", i), landmine.CoordinateCode, landmine.Row, landmine.Col, 0, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffStructFields lists every field that differs between two values of the same struct type
+func diffStructFields(prefix string, oldValue interface{}, newValue interface{}) []string {
+	changes := make([]string, 0)
+	oldStruct := reflect.ValueOf(oldValue)
+	newStruct := reflect.ValueOf(newValue)
+	for i := 0; i < oldStruct.NumField(); i++ {
+		oldField := oldStruct.Field(i).Interface()
+		newField := newStruct.Field(i).Interface()
+		if !reflect.DeepEqual(oldField, newField) {
+			changes = append(changes, fmt.Sprintf("%v %v: %v -> %v", prefix, oldStruct.Type().Field(i).Name, oldField, newField))
+		}
+	}
+	return changes
+}
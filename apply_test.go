@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"gopkg.in/yaml.v3"
+)
+
+// readTestDump writes the dump-json output of a fixture and reads it back like apply-json does
+func readTestDump(t *testing.T, name string) *SaveDump {
+	t.Helper()
+	session := openTestSave(t, name)
+	dumpFilename := filepath.Join(t.TempDir(), "dump.json")
+	dumpFile, err := os.Create(dumpFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSaveDumpJson(dumpFile, session.Save); err != nil {
+		t.Fatal(err)
+	}
+	if err := dumpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+	saveDump, err := ReadSaveDump(dumpFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return saveDump
+}
+
+func TestApplySaveDumpUnchanged(t *testing.T) {
+	session := openTestSave(t, "conquest.sav")
+	changes, err := ApplySaveDump(session.Save, readTestDump(t, "conquest.sav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got:\n%v", strings.Join(changes, "\n"))
+	}
+}
+
+func TestApplySaveDumpMovesUnit(t *testing.T) {
+	session := openTestSave(t, "conquest.sav")
+	saveDump := readTestDump(t, "conquest.sav")
+
+	// move unit 0 from (row 0, col 3) to (row 0, col 2): the code, the derived position and the owner grid
+	gameMode := int(session.Save.SaveHeader.GameMode)
+	saveDump.Units[0].CoordinateCode = uint16(fileio.BuildCoordinateCode(0, 2, session.Save.UnitOwnerData, gameMode))
+	saveDump.Units[0].Col = 2
+	saveDump.UnitOwners[0][3] = fileio.NoTileOwner
+	saveDump.UnitOwners[0][2] = 0
+	saveDump.Players[1].Currency[0] = 999
+
+	changes, err := ApplySaveDump(session.Save, saveDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 4 {
+		t.Errorf("expected 4 changes, got:\n%v", strings.Join(changes, "\n"))
+	}
+	row, col := fileio.ConvertCoordinates(int(session.Save.Units[0].CoordinateCode), session.Save.UnitOwnerData, gameMode)
+	if row != 0 || col != 2 || fileio.GetTileOwner(session.Save, row, col) != 0 {
+		t.Errorf("expected unit 0 at (row 0, col 2) owned by player 0, got (row %v, col %v)", row, col)
+	}
+	if session.Save.PlayerData[1].Currency[0] != 999 {
+		t.Errorf("expected player 1 currency 999, got %v", session.Save.PlayerData[1].Currency[0])
+	}
+}
+
+func TestApplySaveDumpYaml(t *testing.T) {
+	saveDump := readTestDump(t, "campaign.sav")
+	jsonData, err := json.Marshal(saveDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(jsonData, &document); err != nil {
+		t.Fatal(err)
+	}
+	yamlData, err := yaml.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlFilename := filepath.Join(t.TempDir(), "dump.yaml")
+	if err := os.WriteFile(yamlFilename, yamlData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlDump, err := ReadSaveDump(yamlFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := ApplySaveDump(openTestSave(t, "campaign.sav").Save, yamlDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got:\n%v", strings.Join(changes, "\n"))
+	}
+}
+
+func TestValidateSaveDump(t *testing.T) {
+	testCases := []struct {
+		name     string
+		edit     func(saveDump *SaveDump)
+		expected string
+	}{
+		{"map width", func(d *SaveDump) { d.Header.MapWidth += 1 }, "Header.MapWidth changes the file layout"},
+		{"unit count", func(d *SaveDump) { d.Header.UnitCount += 1 }, "Header.UnitCount changes the file layout"},
+		{"missing player", func(d *SaveDump) { d.Players = d.Players[:2] }, "dump has 2 players, save has 3"},
+		{"player index", func(d *SaveDump) { d.Players[1].Index = 2 }, "player at position 1 has index 2"},
+		{"city tile row", func(d *SaveDump) { d.CityTiles[2] = d.CityTiles[2][:5] }, "city tile row 2 has 5 columns"},
+		{"unit owner rows", func(d *SaveDump) { d.UnitOwners = d.UnitOwners[:4] }, "dump has 4 unit owner rows"},
+		{"unit owner", func(d *SaveDump) { d.UnitOwners[2][2] = 3 }, "unit owner at (2, 2) is 3"},
+		{"city owner", func(d *SaveDump) { d.Cities[0].Owner = 2 }, "City 0 has owner 2"},
+		{"missing unit", func(d *SaveDump) { d.Units = d.Units[:3] }, "dump has 3 units, save has 4"},
+		{"unit row", func(d *SaveDump) { d.Units[0].Row = 1 }, "edit CoordinateCode to move it"},
+		{"unit owner field", func(d *SaveDump) { d.Units[2].Owner = 0 }, "edit UnitOwners to change the owner"},
+		{"general unit", func(d *SaveDump) { d.Generals[0].UnitIndex = 9 }, "general refers to unit 9"},
+		{"general field", func(d *SaveDump) { d.Generals[0].GeneralMilitaryRank = 4 }, "edit generals through Units"},
+		{"landmine index", func(d *SaveDump) { d.Landmines[0].Index = 1 }, "landmine at position 0 has index 1"},
+		{"landmine col", func(d *SaveDump) { d.Landmines[0].Col = 1 }, "Landmine 0 is at (0, 1)"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			session := openTestSave(t, "conquest.sav")
+			saveDump := readTestDump(t, "conquest.sav")
+			testCase.edit(saveDump)
+
+			err := validateSaveDump(session.Save, saveDump)
+			if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+				t.Fatalf("expected an error containing %q, got %v", testCase.expected, err)
+			}
+			if _, err := ApplySaveDump(session.Save, saveDump); err == nil {
+				t.Error("ApplySaveDump applied a dump that fails validation")
+			}
+			if changes, err := session.Changes(); err != nil || len(changes) > 0 {
+				t.Errorf("expected the save to be unchanged, got %v (%v)", changes, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+var errNoPlayers = errors.New("the save has no players")
+
+// maxMoney sets every currency of player 0 to 9999, like the max-money command
+func maxMoney(session *fileio.Session) (string, error) {
+	if len(session.Save.PlayerData) == 0 {
+		return "", errNoPlayers
+	}
+	for currency := 0; currency < fileio.CurrencyCount; currency++ {
+		if err := session.SetCurrency(0, currency, 9999); err != nil {
+			return "", err
+		}
+	}
+	return "Set max currency to 9999 for player 0", nil
+}
+
+// restoreAllies heals every unit on player 0's team, like the restore-allies command
+func restoreAllies(session *fileio.Session) (string, error) {
+	if len(session.Save.PlayerData) == 0 {
+		return "", errNoPlayers
+	}
+	healedUnits, err := session.RestoreAllies(0)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Restored allies. Changed %v units to have max health.", len(healedUnits)), nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+// editor holds the open save. Edits are staged in the session in memory
+// and committed to the file when the user saves.
+type editor struct {
+	window   fyne.Window
+	session  *fileio.Session
+	modified bool
+
+	status        *widget.Label
+	tileInfo      *widget.Label
+	mapView       *mapView
+	recordTables  []*recordTable
+	recordEditors []*recordEditor
+}
+
+func newEditor(window fyne.Window) *editor {
+	return &editor{
+		window:   window,
+		status:   widget.NewLabel("Open a save file to start editing"),
+		tileInfo: widget.NewLabel("Click a tile to see its owner, city and unit"),
+	}
+}
+
+// saveOutput returns the save being edited, or nil before one is opened
+func (e *editor) saveOutput() *fileio.WC4SaveOutput {
+	if e.session == nil {
+		return nil
+	}
+	return e.session.Save
+}
+
+func (e *editor) buildContent() fyne.CanvasObject {
+	toolbar := container.NewHBox(
+		widget.NewButton("Open...", e.showOpenDialog),
+		widget.NewButton("Save", e.save),
+		widget.NewSeparator(),
+		widget.NewButton("Max money (player 0)", func() { e.runAction(maxMoney) }),
+		widget.NewButton("Restore allies (player 0)", func() { e.runAction(restoreAllies) }),
+	)
+
+	e.mapView = newMapView(e.showTileInfo)
+	mapTab := container.NewBorder(nil, e.tileInfo, nil, nil, container.NewScroll(e.mapView))
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Map", mapTab),
+		container.NewTabItem("Players", e.buildPlayersTab()),
+		container.NewTabItem("Cities", e.buildCitiesTab()),
+		container.NewTabItem("Units", e.buildUnitsTab()),
+		container.NewTabItem("Generals", e.buildGeneralsTab()),
+	)
+	return container.NewBorder(toolbar, e.status, nil, nil, tabs)
+}
+
+func (e *editor) showOpenDialog() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		e.openSave(reader.URI().Path())
+	}, e.window)
+}
+
+func (e *editor) openSave(filename string) {
+	session, err := fileio.Open(filename)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.session = session
+	e.modified = false
+	e.refresh()
+	e.clearRecordEditors()
+	saveOutput := session.Save
+	e.setStatus(fmt.Sprintf("Opened %v: %vx%v map, %v players, %v cities, %v units",
+		filepath.Base(filename), saveOutput.SaveHeader.MapWidth, saveOutput.SaveHeader.MapHeight,
+		len(saveOutput.PlayerData), len(saveOutput.Cities), len(saveOutput.Units)))
+}
+
+func (e *editor) save() {
+	if e.session == nil {
+		return
+	}
+	filename := filepath.Base(e.session.Path)
+	changes, err := e.session.Changes()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	if len(changes) == 0 {
+		e.modified = false
+		e.setStatus("No changes to write to " + filename)
+		return
+	}
+
+	backupFilename, err := fileio.BackupSaveFile(e.session.Path)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	if err := e.session.Commit(); err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	// Commit parses the written file again, so the records shown in the editors are stale
+	e.modified = false
+	e.refresh()
+	e.clearRecordEditors()
+	e.setStatus("Saved " + filename + ", backup in " + filepath.Base(backupFilename))
+}
+
+func (e *editor) runAction(action func(session *fileio.Session) (string, error)) {
+	if e.session == nil {
+		return
+	}
+	message, err := action(e.session)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.markModified()
+	e.setStatus(message + " (not saved yet)")
+}
+
+func (e *editor) markModified() {
+	e.modified = true
+	e.refresh()
+}
+
+func (e *editor) refresh() {
+	e.mapView.setSave(e.saveOutput())
+	for _, table := range e.recordTables {
+		table.table.Refresh()
+	}
+}
+
+// clearRecordEditors closes the record forms, whose records belong to a save that was replaced
+func (e *editor) clearRecordEditors() {
+	for _, recordEditor := range e.recordEditors {
+		recordEditor.clear()
+	}
+}
+
+func (e *editor) setStatus(message string) {
+	if e.modified && !strings.HasSuffix(message, "(not saved yet)") {
+		message += " (unsaved changes)"
+	}
+	e.status.SetText(message)
+}
+
+func (e *editor) showTileInfo(row int, col int) {
+	saveOutput := e.saveOutput()
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	lines := make([]string, 0)
+
+	owner := fileio.GetTileOwner(saveOutput, row, col)
+	if owner == 255 {
+		lines = append(lines, fmt.Sprintf("Tile (row %v, column %v): no owner", row, col))
+	} else if owner >= 0 && owner < len(saveOutput.PlayerData) {
+		player := saveOutput.PlayerData[owner]
+		lines = append(lines, fmt.Sprintf("Tile (row %v, column %v): player %v (CountryId %v, TeamId %v)", row, col, owner, player.CountryId, player.TeamId))
+	} else {
+		lines = append(lines, fmt.Sprintf("Tile (row %v, column %v): invalid owner %v", row, col, owner))
+	}
+
+	for i, city := range saveOutput.Cities {
+		cityRow, cityCol := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		if cityRow == row && cityCol == col {
+			lines = append(lines, fmt.Sprintf("City %v: CityId %v, BuildingType %v, TechLevels %v", i, city.CityId, city.BuildingType, city.TechLevels))
+		} else if row < len(saveOutput.CityTiles) && col < len(saveOutput.CityTiles[row]) && saveOutput.CityTiles[row][col] == city.CoordinateCode {
+			lines = append(lines, fmt.Sprintf("Territory of city %v (CityId %v)", i, city.CityId))
+		}
+	}
+
+	for i, unit := range saveOutput.Units {
+		unitRow, unitCol := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		if unitRow == row && unitCol == col {
+			line := fmt.Sprintf("Unit %v: UnitType %v, Level %v, Health %v/%v", i, unit.UnitType, unit.Level, unit.CurrentHealth, unit.MaxHealth)
+			if unit.GeneralId > 0 {
+				line += fmt.Sprintf(", GeneralId %v", unit.GeneralId)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	e.tileInfo.SetText(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,26 @@
+// Command wc4gui is a desktop editor for World Conqueror 4 save files.
+//
+// Usage:
+//
+//	wc4gui [save file]
+package main
+
+import (
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+)
+
+func main() {
+	editorApp := app.New()
+	window := editorApp.NewWindow("WC4 Save Editor")
+	saveEditor := newEditor(window)
+	window.SetContent(saveEditor.buildContent())
+	window.Resize(fyne.NewSize(1200, 800))
+
+	if len(os.Args) > 1 {
+		saveEditor.openSave(os.Args[1])
+	}
+	window.ShowAndRun()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+const mapTileSize = 12
+
+var (
+	cityColor = color.RGBA{255, 255, 255, 255}
+	unitColor = color.RGBA{0, 0, 0, 255}
+)
+
+type tileKey struct {
+	row int
+	col int
+}
+
+// mapView draws the ownership grid and reports which tile was clicked
+type mapView struct {
+	widget.BaseWidget
+
+	saveOutput   *fileio.WC4SaveOutput
+	tileColors   [][]color.RGBA
+	cityTiles    map[tileKey]bool
+	unitTiles    map[tileKey]bool
+	raster       *canvas.Raster
+	onTileTapped func(row int, col int)
+}
+
+func newMapView(onTileTapped func(row int, col int)) *mapView {
+	view := &mapView{onTileTapped: onTileTapped}
+	view.raster = canvas.NewRasterWithPixels(view.pixelColor)
+	view.ExtendBaseWidget(view)
+	return view
+}
+
+func (m *mapView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.raster)
+}
+
+// setSave recomputes the tile colors after the save was opened or edited
+func (m *mapView) setSave(saveOutput *fileio.WC4SaveOutput) {
+	m.saveOutput = saveOutput
+	m.tileColors = nil
+	m.cityTiles = make(map[tileKey]bool)
+	m.unitTiles = make(map[tileKey]bool)
+	if saveOutput == nil || len(saveOutput.UnitOwnerData) == 0 {
+		m.raster.Refresh()
+		return
+	}
+
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	for _, city := range saveOutput.Cities {
+		row, col := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		m.cityTiles[tileKey{row, col}] = true
+	}
+	for _, unit := range saveOutput.Units {
+		row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		m.unitTiles[tileKey{row, col}] = true
+	}
+	m.tileColors = fileio.TileColors(saveOutput)
+
+	mapWidth := len(saveOutput.UnitOwnerData[0])
+	mapHeight := len(saveOutput.UnitOwnerData)
+	m.raster.SetMinSize(fyne.NewSize(float32(mapWidth*mapTileSize), float32(mapHeight*mapTileSize)))
+	m.raster.Refresh()
+	m.Refresh()
+}
+
+func (m *mapView) pixelColor(x int, y int, width int, height int) color.Color {
+	if len(m.tileColors) == 0 || width == 0 || height == 0 {
+		return fileio.EmptyTileColor
+	}
+	mapHeight := len(m.tileColors)
+	mapWidth := len(m.tileColors[0])
+
+	// position inside the map in tiles, the fraction is the position inside the tile
+	tileX := float64(x) * float64(mapWidth) / float64(width)
+	tileY := float64(y) * float64(mapHeight) / float64(height)
+	col := int(tileX)
+	row := int(tileY)
+	if row >= mapHeight || col >= mapWidth {
+		return fileio.EmptyTileColor
+	}
+	insideX := tileX - float64(col)
+	insideY := tileY - float64(row)
+
+	key := tileKey{row, col}
+	if m.cityTiles[key] && insideX > 0.25 && insideX < 0.75 && insideY > 0.25 && insideY < 0.75 {
+		return cityColor
+	}
+	if m.unitTiles[key] && (insideX-0.5)*(insideX-0.5)+(insideY-0.5)*(insideY-0.5) < 0.05 {
+		return unitColor
+	}
+	if insideX < 0.06 || insideY < 0.06 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	return m.tileColors[row][col]
+}
+
+func (m *mapView) Tapped(event *fyne.PointEvent) {
+	if len(m.tileColors) == 0 {
+		return
+	}
+	size := m.Size()
+	col := int(event.Position.X / size.Width * float32(len(m.tileColors[0])))
+	row := int(event.Position.Y / size.Height * float32(len(m.tileColors)))
+	if row < 0 || row >= len(m.tileColors) || col < 0 || col >= len(m.tileColors[0]) {
+		return
+	}
+	m.onTileTapped(row, col)
+}
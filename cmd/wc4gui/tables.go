@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+type tableColumn struct {
+	title string
+	width float32
+	value func(row int) string
+}
+
+// recordTable shows one row per record with a header row of column titles
+type recordTable struct {
+	columns  []tableColumn
+	rowCount func() int
+	table    *widget.Table
+}
+
+func newRecordTable(columns []tableColumn, rowCount func() int, onSelected func(row int)) *recordTable {
+	recordTable := &recordTable{columns: columns, rowCount: rowCount}
+	table := widget.NewTable(
+		func() (int, int) {
+			return rowCount(), len(columns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			cell.(*widget.Label).SetText(columns[id.Col].value(id.Row))
+		},
+	)
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, header fyne.CanvasObject) {
+		header.(*widget.Label).SetText(columns[id.Col].title)
+	}
+	for i, column := range columns {
+		table.SetColumnWidth(i, column.width)
+	}
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Row >= 0 && id.Row < rowCount() {
+			onSelected(id.Row)
+		}
+	}
+	recordTable.table = table
+	return recordTable
+}
+
+// recordEditor shows an entry for every known field of the selected record
+type recordEditor struct {
+	editor      *editor
+	includeName func(name string) bool
+	panel       *fyne.Container
+}
+
+func newRecordEditor(e *editor, includeName func(name string) bool) *recordEditor {
+	recordEditor := &recordEditor{
+		editor:      e,
+		includeName: includeName,
+		panel:       container.NewVBox(),
+	}
+	recordEditor.clear()
+	return recordEditor
+}
+
+func (r *recordEditor) clear() {
+	r.panel.Objects = []fyne.CanvasObject{widget.NewLabel("Select a row to edit it")}
+	r.panel.Refresh()
+}
+
+// show builds the form for the record that recordPtr points to. Applying the
+// form stores every field with setField, one of the Session field setters.
+func (r *recordEditor) show(title string, recordPtr interface{}, setField func(fieldName string, value int64) (int64, error)) {
+	fields := make([]fileio.RecordField, 0)
+	entries := make([]*widget.Entry, 0)
+	formItems := make([]*widget.FormItem, 0)
+	for _, field := range fileio.ExpandRecordFields(reflect.ValueOf(recordPtr).Elem().Interface()) {
+		if !r.includeName(field.Name) {
+			continue
+		}
+		entry := widget.NewEntry()
+		entry.SetText(fmt.Sprint(field.GetValue(recordPtr)))
+		fields = append(fields, field)
+		entries = append(entries, entry)
+		formItems = append(formItems, widget.NewFormItem(field.Name, entry))
+	}
+
+	form := widget.NewForm(formItems...)
+	form.SubmitText = "Apply"
+	form.OnSubmit = func() {
+		values := make([]int64, len(fields))
+		for i, field := range fields {
+			var value int64
+			if _, err := fmt.Sscan(strings.TrimSpace(entries[i].Text), &value); err != nil {
+				r.editor.setStatus(fmt.Sprintf("%v: %q is not a number", field.Name, entries[i].Text))
+				return
+			}
+			if err := field.CheckValue(value); err != nil {
+				r.editor.setStatus(err.Error())
+				return
+			}
+			values[i] = value
+		}
+		for i, field := range fields {
+			if _, err := setField(field.Name, values[i]); err != nil {
+				r.editor.setStatus(err.Error())
+				return
+			}
+		}
+		r.editor.markModified()
+		r.editor.setStatus("Updated " + title + " (not saved yet)")
+	}
+
+	r.panel.Objects = []fyne.CanvasObject{
+		widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form,
+	}
+	r.panel.Refresh()
+}
+
+func (e *editor) buildRecordTab(columns []tableColumn, rowCount func() int, includeName func(name string) bool, onSelected func(row int, recordEditor *recordEditor)) fyne.CanvasObject {
+	recordEditor := newRecordEditor(e, includeName)
+	table := newRecordTable(columns, rowCount, func(row int) {
+		onSelected(row, recordEditor)
+	})
+	e.recordTables = append(e.recordTables, table)
+	e.recordEditors = append(e.recordEditors, recordEditor)
+
+	split := container.NewHSplit(table.table, container.NewVScroll(recordEditor.panel))
+	split.SetOffset(0.65)
+	return split
+}
+
+func isKnownField(name string) bool {
+	return !strings.HasPrefix(name, "Unknown")
+}
+
+func (e *editor) saveOutputPlayers() []fileio.CountryData {
+	if e.saveOutput() == nil {
+		return nil
+	}
+	return e.saveOutput().PlayerData
+}
+
+func (e *editor) saveOutputCities() []fileio.CityData {
+	if e.saveOutput() == nil {
+		return nil
+	}
+	return e.saveOutput().Cities
+}
+
+func (e *editor) saveOutputUnits() []fileio.UnitData {
+	if e.saveOutput() == nil {
+		return nil
+	}
+	return e.saveOutput().Units
+}
+
+func (e *editor) unitOwnerAt(coordinateCode uint16) int {
+	row, col := fileio.ConvertCoordinates(int(coordinateCode), e.saveOutput().UnitOwnerData, int(e.saveOutput().SaveHeader.GameMode))
+	return fileio.GetTileOwner(e.saveOutput(), row, col)
+}
+
+func (e *editor) locationOf(coordinateCode uint16) string {
+	row, col := fileio.ConvertCoordinates(int(coordinateCode), e.saveOutput().UnitOwnerData, int(e.saveOutput().SaveHeader.GameMode))
+	return fmt.Sprintf("(%v, %v)", row, col)
+}
+
+func (e *editor) buildPlayersTab() fyne.CanvasObject {
+	player := func(row int) fileio.CountryData { return e.saveOutput().PlayerData[row] }
+	columns := []tableColumn{
+		{"Player", 60, func(row int) string { return fmt.Sprint(row) }},
+		{"CountryId", 90, func(row int) string { return fmt.Sprint(player(row).CountryId) }},
+		{"TeamId", 70, func(row int) string { return fmt.Sprint(player(row).TeamId) }},
+		{"Currency", 180, func(row int) string { return fmt.Sprint(player(row).Currency) }},
+		{"Bot", 50, func(row int) string { return fmt.Sprint(player(row).BotFlag) }},
+		{"Tiles owned", 100, func(row int) string {
+			count := 0
+			for _, unitOwnerRow := range e.saveOutput().UnitOwnerData {
+				for _, owner := range unitOwnerRow {
+					if int(owner) == row {
+						count += 1
+					}
+				}
+			}
+			return fmt.Sprint(count)
+		}},
+	}
+	return e.buildRecordTab(columns, func() int { return len(e.saveOutputPlayers()) }, isKnownField, func(row int, recordEditor *recordEditor) {
+		recordEditor.show(fmt.Sprintf("Player %v", row), &e.saveOutput().PlayerData[row], func(fieldName string, value int64) (int64, error) {
+			return e.session.SetPlayerField(row, fieldName, value)
+		})
+	})
+}
+
+func (e *editor) buildCitiesTab() fyne.CanvasObject {
+	city := func(row int) fileio.CityData { return e.saveOutput().Cities[row] }
+	columns := []tableColumn{
+		{"City", 50, func(row int) string { return fmt.Sprint(row) }},
+		{"Location", 90, func(row int) string { return e.locationOf(city(row).CoordinateCode) }},
+		{"Owner", 60, func(row int) string { return fmt.Sprint(e.unitOwnerAt(city(row).CoordinateCode)) }},
+		{"CityId", 60, func(row int) string { return fmt.Sprint(city(row).CityId) }},
+		{"Building", 70, func(row int) string { return fmt.Sprint(city(row).BuildingType) }},
+		{"Wonders", 70, func(row int) string { return fmt.Sprint(city(row).Wonders) }},
+		{"Anti-air", 80, func(row int) string { return fmt.Sprintf("%v/%v", city(row).AntiAirWeaponType, city(row).AntiAirRange) }},
+		{"TechLevels", 140, func(row int) string { return fmt.Sprint(city(row).TechLevels) }},
+	}
+	return e.buildRecordTab(columns, func() int { return len(e.saveOutputCities()) }, isKnownField, func(row int, recordEditor *recordEditor) {
+		recordEditor.show(fmt.Sprintf("City %v", row), &e.saveOutput().Cities[row], func(fieldName string, value int64) (int64, error) {
+			return e.session.SetCityField(row, fieldName, value)
+		})
+	})
+}
+
+func (e *editor) buildUnitsTab() fyne.CanvasObject {
+	unit := func(row int) fileio.UnitData { return e.saveOutput().Units[row] }
+	columns := []tableColumn{
+		{"Unit", 50, func(row int) string { return fmt.Sprint(row) }},
+		{"Location", 90, func(row int) string { return e.locationOf(unit(row).CoordinateCode) }},
+		{"Owner", 60, func(row int) string { return fmt.Sprint(e.unitOwnerAt(unit(row).CoordinateCode)) }},
+		{"UnitType", 70, func(row int) string { return fmt.Sprint(unit(row).UnitType) }},
+		{"Level", 50, func(row int) string { return fmt.Sprint(unit(row).Level) }},
+		{"Health", 90, func(row int) string { return fmt.Sprintf("%v/%v", unit(row).CurrentHealth, unit(row).MaxHealth) }},
+		{"Experience", 90, func(row int) string { return fmt.Sprint(unit(row).Experience) }},
+		{"GeneralId", 80, func(row int) string { return fmt.Sprint(unit(row).GeneralId) }},
+	}
+	return e.buildRecordTab(columns, func() int { return len(e.saveOutputUnits()) }, isKnownField, func(row int, recordEditor *recordEditor) {
+		recordEditor.show(fmt.Sprintf("Unit %v", row), &e.saveOutput().Units[row], func(fieldName string, value int64) (int64, error) {
+			return e.session.SetUnitField(row, fieldName, value)
+		})
+	})
+}
+
+// generalUnits returns the indices of units led by a general
+func (e *editor) generalUnits() []int {
+	unitIndices := make([]int, 0)
+	for i, unit := range e.saveOutputUnits() {
+		if unit.GeneralId > 0 {
+			unitIndices = append(unitIndices, i)
+		}
+	}
+	return unitIndices
+}
+
+func (e *editor) buildGeneralsTab() fyne.CanvasObject {
+	unit := func(row int) fileio.UnitData { return e.saveOutput().Units[e.generalUnits()[row]] }
+	columns := []tableColumn{
+		{"Unit", 50, func(row int) string { return fmt.Sprint(e.generalUnits()[row]) }},
+		{"Owner", 60, func(row int) string { return fmt.Sprint(e.unitOwnerAt(unit(row).CoordinateCode)) }},
+		{"GeneralId", 80, func(row int) string { return fmt.Sprint(unit(row).GeneralId) }},
+		{"Rank", 50, func(row int) string { return fmt.Sprint(unit(row).GeneralMilitaryRank) }},
+		{"Title", 50, func(row int) string { return fmt.Sprint(unit(row).GeneralTitle) }},
+		{"Badges", 90, func(row int) string { return fmt.Sprint(unit(row).GeneralBadges) }},
+		{"Skills", 120, func(row int) string { return fmt.Sprint(unit(row).GeneralSkillLevels) }},
+	}
+	isGeneralField := func(name string) bool {
+		return strings.HasPrefix(name, "General")
+	}
+	return e.buildRecordTab(columns, func() int { return len(e.generalUnits()) }, isGeneralField, func(row int, recordEditor *recordEditor) {
+		unitIndex := e.generalUnits()[row]
+		recordEditor.show(fmt.Sprintf("General on unit %v", unitIndex), &e.saveOutput().Units[unitIndex], func(fieldName string, value int64) (int64, error) {
+			return e.session.SetUnitField(unitIndex, fieldName, value)
+		})
+	})
+}
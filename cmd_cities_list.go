@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newCitiesListCommand())
+}
+
+func newCitiesListCommand() *command {
+	cmd := newCommand("cities list", "List every city with its owner and location.")
+	cmd.aliases = []string{"list-cities"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Map rows:", len(saveOutput.UnitOwnerData), ", columns:", len(saveOutput.UnitOwnerData[0]))
+		for i := 0; i < len(saveOutput.Cities); i++ {
+			city := saveOutput.Cities[i]
+			row, col := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+			fmt.Printf("City %v (city: %v, wonder: %v, owner: %v, row: %v, column: %v): %+v\n", i, gamedata.Cities.Format(int(city.CityId)),
+				gamedata.Wonders.Format(int(city.Wonders)), fileio.GetTileOwner(saveOutput, row, col), row, col, city)
+		}
+		return nil
+	}
+	return cmd
+}
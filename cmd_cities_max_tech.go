@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newCitiesMaxTechCommand())
+}
+
+func newCitiesMaxTechCommand() *command {
+	cmd := newCommand("cities max-tech", "Set every tech level of a player's cities to level 4.")
+	cmd.aliases = []string{"max-city-tech"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		player := *playerPtr
+		if err := checkPlayerIndex(saveOutput, "player", player); err != nil {
+			return err
+		}
+
+		for i := 0; i < len(saveOutput.Cities); i++ {
+			city := saveOutput.Cities[i]
+			row, col := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+			if fileio.GetTileOwner(saveOutput, row, col) != player {
+				continue
+			}
+
+			for techCount := 0; techCount < fileio.CityTechCount; techCount++ {
+				if err := session.SetCityTechLevel(i, techCount, fileio.MaxCityTechLevel); err != nil {
+					return err
+				}
+			}
+			fmt.Println("Set tech levels of city", i, "to level", fileio.MaxCityTechLevel)
+		}
+
+		fmt.Println("Set max city tech to level", fileio.MaxCityTechLevel, "for player", player)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newCitiesSetCommand())
+}
+
+// parseCityTechLevels parses a list like "0=4,2=3" or "all=4" into levels keyed by tech category
+func parseCityTechLevels(value string) (map[int]int, error) {
+	levels := make(map[int]int)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("-tech: %q is not of the form tech=level", entry)
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("-tech: level %q is not a number", parts[1])
+		}
+		if strings.EqualFold(strings.TrimSpace(parts[0]), "all") {
+			for category := 0; category < fileio.CityTechCount; category++ {
+				levels[category] = level
+			}
+			continue
+		}
+		category, err := gamedata.CityTechs.Lookup(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("-tech: %w", err)
+		}
+		levels[category] = level
+	}
+	return levels, nil
+}
+
+func newCitiesSetCommand() *command {
+	cmd := newCommand("cities set", "Set the building, wonder, anti-air and tech levels of cities, or any field with -field and -value.")
+	cmd.aliases = []string{"set-city"}
+	addWriteSaveFlags(cmd)
+	fieldFlags := addRecordFieldFlags(cmd.flags, fileio.CityData{})
+	cmd.flags.Lookup("index").Usage = "index of the city to change"
+	idFlag := &optionalString{}
+	cmd.flags.Var(idFlag, "id", "change the city with this city id number or name")
+	xFlag, yFlag, ownerFlag := &optionalInt{}, &optionalInt{}, &optionalInt{}
+	cmd.flags.Var(xFlag, "x", "column of the city center to change, used with -y")
+	cmd.flags.Var(yFlag, "y", "row of the city center to change, used with -x")
+	cmd.flags.Var(ownerFlag, "owner", "change every city of this player")
+	buildingFlag, wonderFlag, antiAirTypeFlag := &optionalString{}, &optionalString{}, &optionalString{}
+	cmd.flags.Var(buildingFlag, "building", "building type number or name")
+	cmd.flags.Var(wonderFlag, "wonder", "wonder number or name")
+	cmd.flags.Var(antiAirTypeFlag, "antiair-type", "anti-air weapon type number or name")
+	antiAirRangeFlag := &optionalInt{}
+	cmd.flags.Var(antiAirRangeFlag, "antiair-range", fmt.Sprintf("anti-air range between 0 and %v", math.MaxUint8))
+	techFlag := &optionalString{}
+	cmd.flags.Var(techFlag, "tech", fmt.Sprintf("tech levels between 0 and %v by category number or name, e.g. 0=4,2=3 or all=4", fileio.MaxCityTechLevel))
+
+	// lookupCityValue resolves the number or name given to a flag and checks it against the table
+	lookupCityValue := func(flagName string, table *gamedata.NameTable, value string) (int, error) {
+		id, err := table.Lookup(value)
+		if err != nil {
+			return -1, fmt.Errorf("-%v: %w", flagName, err)
+		}
+		if err := table.Check(id, math.MaxUint8); err != nil {
+			return -1, fmt.Errorf("-%v: %w", flagName, err)
+		}
+		return id, nil
+	}
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		byIndex := fieldFlags.index.set
+		byId := idFlag.set
+		byTile := xFlag.set || yFlag.set
+		byOwner := ownerFlag.set
+		selectionCount := 0
+		for _, selected := range []bool{byIndex, byId, byTile, byOwner} {
+			if selected {
+				selectionCount += 1
+			}
+		}
+		if selectionCount != 1 {
+			return fmt.Errorf("select cities with exactly one of -index, -id, -x and -y, or -owner")
+		}
+		if *fieldFlags.field == "" && !buildingFlag.set && !wonderFlag.set && !antiAirTypeFlag.set && !antiAirRangeFlag.set && !techFlag.set {
+			return fmt.Errorf("nothing to change, give at least one of -building, -wonder, -antiair-type, -antiair-range, -tech and -field")
+		}
+
+		cityIndices := make([]int, 0)
+		switch {
+		case byIndex:
+			if fieldFlags.index.value < 0 || fieldFlags.index.value >= len(saveOutput.Cities) {
+				return fmt.Errorf("-index must be between 0 and %v, got %v", len(saveOutput.Cities)-1, fieldFlags.index.value)
+			}
+			cityIndices = append(cityIndices, fieldFlags.index.value)
+		case byId:
+			cityId, err := gamedata.Cities.Lookup(idFlag.value)
+			if err != nil {
+				return err
+			}
+			cityIndex := session.FindCityById(cityId)
+			if cityIndex < 0 {
+				return fmt.Errorf("no city with id %v", gamedata.Cities.Format(cityId))
+			}
+			cityIndices = append(cityIndices, cityIndex)
+		case byTile:
+			if !xFlag.set || !yFlag.set {
+				return fmt.Errorf("-x and -y must be given together")
+			}
+			if err := checkTile(saveOutput, xFlag.value, yFlag.value); err != nil {
+				return err
+			}
+			cityIndex := session.FindCityAt(xFlag.value, yFlag.value)
+			if cityIndex < 0 {
+				return fmt.Errorf("no city at (x: %v, y: %v)", xFlag.value, yFlag.value)
+			}
+			cityIndices = append(cityIndices, cityIndex)
+		case byOwner:
+			if err := checkPlayerIndex(saveOutput, "owner", ownerFlag.value); err != nil {
+				return err
+			}
+			cityIndices = session.FindCitiesOwnedBy(ownerFlag.value)
+			if len(cityIndices) == 0 {
+				return fmt.Errorf("player %v has no cities", ownerFlag.value)
+			}
+		}
+
+		var techLevels map[int]int
+		if techFlag.set {
+			levels, err := parseCityTechLevels(techFlag.value)
+			if err != nil {
+				return err
+			}
+			techLevels = levels
+		}
+		var building, wonder, antiAirType int
+		var err error
+		if buildingFlag.set {
+			if building, err = lookupCityValue("building", gamedata.BuildingTypes, buildingFlag.value); err != nil {
+				return err
+			}
+		}
+		if wonderFlag.set {
+			if wonder, err = lookupCityValue("wonder", gamedata.Wonders, wonderFlag.value); err != nil {
+				return err
+			}
+		}
+		if antiAirTypeFlag.set {
+			if antiAirType, err = lookupCityValue("antiair-type", gamedata.AntiAirWeapons, antiAirTypeFlag.value); err != nil {
+				return err
+			}
+		}
+		if antiAirRangeFlag.set && (antiAirRangeFlag.value < 0 || antiAirRangeFlag.value > math.MaxUint8) {
+			return fmt.Errorf("-antiair-range must be between 0 and %v, got %v", math.MaxUint8, antiAirRangeFlag.value)
+		}
+
+		for _, cityIndex := range cityIndices {
+			if buildingFlag.set {
+				if err := session.SetCityBuilding(cityIndex, building); err != nil {
+					return err
+				}
+				fmt.Println("Set building of city", cityIndex, "to", gamedata.BuildingTypes.Format(building))
+			}
+			if wonderFlag.set {
+				if err := session.SetCityWonder(cityIndex, wonder); err != nil {
+					return err
+				}
+				fmt.Println("Set wonder of city", cityIndex, "to", gamedata.Wonders.Format(wonder))
+			}
+			if antiAirTypeFlag.set {
+				if err := session.SetCityAntiAirWeapon(cityIndex, antiAirType); err != nil {
+					return err
+				}
+				fmt.Println("Set anti-air weapon of city", cityIndex, "to", gamedata.AntiAirWeapons.Format(antiAirType))
+			}
+			if antiAirRangeFlag.set {
+				if err := session.SetCityAntiAirRange(cityIndex, antiAirRangeFlag.value); err != nil {
+					return err
+				}
+				fmt.Println("Set anti-air range of city", cityIndex, "to", antiAirRangeFlag.value)
+			}
+			for category := 0; category < fileio.CityTechCount; category++ {
+				level, ok := techLevels[category]
+				if !ok {
+					continue
+				}
+				if err := session.SetCityTechLevel(cityIndex, category, level); err != nil {
+					return err
+				}
+				fmt.Println("Set tech", gamedata.CityTechs.Format(category), "of city", cityIndex, "to level", level)
+			}
+			if *fieldFlags.field != "" {
+				value, err := strconv.ParseInt(*fieldFlags.value, 10, 64)
+				if err != nil {
+					return fmt.Errorf("-value must be an integer, got %q", *fieldFlags.value)
+				}
+				oldValue, err := session.SetCityField(cityIndex, *fieldFlags.field, value)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Set city %v %v from %v to %v\n", cityIndex, *fieldFlags.field, oldValue, value)
+			}
+		}
+		return nil
+	}
+	return cmd
+}
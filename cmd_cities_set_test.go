@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCitiesSetRejectsNegativeValues(t *testing.T) {
+	for _, args := range [][]string{
+		{"-index", "0", "-building", "-1"},
+		{"-index", "0", "-wonder", "-1"},
+		{"-index", "0", "-antiair-type", "-1"},
+		{"-index", "0", "-antiair-range", "-1"},
+		{"-index", "-1", "-building", "1"},
+		{"-owner", "-1", "-building", "1"},
+		{"-x", "-1", "-y", "1", "-building", "1"},
+	} {
+		cmd := newCitiesSetCommand()
+		if err := cmd.flags.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		session := openTestSave(t, "conquest.sav")
+		if err := cmd.edit(session); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+		if changes, err := session.Changes(); err != nil || len(changes) > 0 {
+			t.Errorf("%v: expected no changes, got %v (%v)", args, changes, err)
+		}
+	}
+}
+
+func TestCitiesSetOwner(t *testing.T) {
+	cmd := newCitiesSetCommand()
+	if err := cmd.flags.Parse([]string{"-owner", "1", "-building", "0", "-antiair-range", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	session := openTestSave(t, "conquest.sav")
+	session.Save.Cities[1].BuildingType = 7
+	session.Save.Cities[1].AntiAirRange = 3
+	if err := cmd.edit(session); err != nil {
+		t.Fatal(err)
+	}
+	if city := session.Save.Cities[1]; city.BuildingType != 0 || city.AntiAirRange != 0 {
+		t.Errorf("expected building 0 and anti-air range 0, got %v and %v", city.BuildingType, city.AntiAirRange)
+	}
+}
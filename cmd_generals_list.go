@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newGeneralsListCommand())
+}
+
+func newGeneralsListCommand() *command {
+	cmd := newCommand("generals list", "List every unit led by a general.")
+	cmd.aliases = []string{"list-generals"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(saveOutput.Units); i++ {
+			unit := saveOutput.Units[i]
+			if unit.GeneralId == 0 {
+				continue
+			}
+			row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+			fmt.Printf("General %v (unit %v, type: %v, owner: %v, row: %v, column: %v): %+v\n", gamedata.Generals.Format(int(unit.GeneralId)), i,
+				gamedata.UnitTypes.Format(int(unit.UnitType)), fileio.GetTileOwner(saveOutput, row, col), row, col, unit)
+		}
+		return nil
+	}
+	return cmd
+}
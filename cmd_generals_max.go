@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newGeneralsMaxCommand())
+}
+
+func newGeneralsMaxCommand() *command {
+	cmd := newCommand("generals max", "Raise every skill of a player's generals to the highest level.")
+	cmd.aliases = []string{"max-generals"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index whose generals are improved")
+	rankPtr := cmd.flags.String("rank", "", "also set the military rank of the generals to this number or name")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		player := *playerPtr
+		if err := checkPlayerIndex(saveOutput, "player", player); err != nil {
+			return err
+		}
+		rank := -1
+		if *rankPtr != "" {
+			var err error
+			if rank, err = gamedata.GeneralRanks.Lookup(*rankPtr); err != nil {
+				return err
+			}
+		}
+
+		count := 0
+		gameMode := int(saveOutput.SaveHeader.GameMode)
+		for i, unit := range saveOutput.Units {
+			row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+			if unit.GeneralId == 0 || fileio.GetTileOwner(saveOutput, row, col) != player {
+				continue
+			}
+			for skill := 0; skill < gamedata.GeneralSkillCount; skill++ {
+				if err := session.SetGeneralSkill(i, skill, gamedata.MaxGeneralSkillLevel); err != nil {
+					return err
+				}
+			}
+			if rank >= 0 {
+				if err := session.SetGeneralRank(i, rank); err != nil {
+					return err
+				}
+			}
+			fmt.Println("Maxed general", gamedata.Generals.Format(int(unit.GeneralId)), "of unit", i)
+			count += 1
+		}
+		fmt.Println("Maxed", count, "generals of player", player)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newGeneralsSetCommand())
+}
+
+// parseNameList parses a comma separated list of exactly count numbers, or names from table if it isn't nil
+func parseNameList(flagName string, table *gamedata.NameTable, value string, count int) ([]int, error) {
+	values := strings.Split(value, ",")
+	if len(values) != count {
+		return nil, fmt.Errorf("-%v needs %v comma separated values, got %q", flagName, count, value)
+	}
+	ids := make([]int, 0, count)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if table == nil {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("-%v: %q is not a number", flagName, value)
+			}
+			ids = append(ids, id)
+			continue
+		}
+		id, err := table.Lookup(value)
+		if err != nil {
+			return nil, fmt.Errorf("-%v: %w", flagName, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func newGeneralsSetCommand() *command {
+	cmd := newCommand("generals set", "Assign a general to a unit and change its rank, title, badges and skills.")
+	cmd.aliases = []string{"set-general"}
+	addWriteSaveFlags(cmd)
+	unitPtr := cmd.flags.Int("unit", -1, "index of the unit led by the general (required)")
+	generalPtr := cmd.flags.String("general", "", "general number or name to put in command of the unit, 0 removes the general")
+	rankPtr := cmd.flags.String("rank", "", "military rank number or name")
+	titlePtr := cmd.flags.String("title", "", "title number or name")
+	badgesPtr := cmd.flags.String("badges", "", "all three badges as numbers or names, e.g. 1,0,0")
+	skillsPtr := cmd.flags.String("skills", "", fmt.Sprintf("all five skill levels between 0 and %v, e.g. 5,5,5,5,5", gamedata.MaxGeneralSkillLevel))
+	skillPtr := cmd.flags.String("skill", "", "one skill number (0-4) or name to set to -level")
+	levelPtr := cmd.flags.Int("level", -1, "level for -skill")
+
+	cmd.edit = func(session *fileio.Session) error {
+		unitIndex := *unitPtr
+		if unitIndex < 0 || unitIndex >= len(session.Save.Units) {
+			return fmt.Errorf("-unit must be between 0 and %v, got %v", len(session.Save.Units)-1, unitIndex)
+		}
+		if *generalPtr == "" && *rankPtr == "" && *titlePtr == "" && *badgesPtr == "" && *skillsPtr == "" && *skillPtr == "" {
+			return fmt.Errorf("nothing to change, give at least one of -general, -rank, -title, -badges, -skills and -skill")
+		}
+
+		if *generalPtr != "" {
+			generalId, err := gamedata.Generals.Lookup(*generalPtr)
+			if err != nil {
+				return err
+			}
+			if err := session.AssignGeneral(unitIndex, generalId); err != nil {
+				return err
+			}
+			if generalId == 0 {
+				fmt.Println("Removed the general of unit", unitIndex)
+			} else {
+				fmt.Println("Assigned general", gamedata.Generals.Format(generalId), "to unit", unitIndex)
+			}
+		}
+		if *rankPtr != "" {
+			rank, err := gamedata.GeneralRanks.Lookup(*rankPtr)
+			if err != nil {
+				return err
+			}
+			if err := session.SetGeneralRank(unitIndex, rank); err != nil {
+				return err
+			}
+			fmt.Println("Set rank of unit", unitIndex, "to", gamedata.GeneralRanks.Format(rank))
+		}
+		if *titlePtr != "" {
+			title, err := gamedata.GeneralTitles.Lookup(*titlePtr)
+			if err != nil {
+				return err
+			}
+			if err := session.SetGeneralTitle(unitIndex, title); err != nil {
+				return err
+			}
+			fmt.Println("Set title of unit", unitIndex, "to", gamedata.GeneralTitles.Format(title))
+		}
+		if *badgesPtr != "" {
+			badges, err := parseNameList("badges", gamedata.GeneralBadges, *badgesPtr, gamedata.GeneralBadgeCount)
+			if err != nil {
+				return err
+			}
+			for slot, badge := range badges {
+				if err := session.SetGeneralBadge(unitIndex, slot, badge); err != nil {
+					return err
+				}
+			}
+			fmt.Println("Set badges of unit", unitIndex, "to", badges)
+		}
+		if *skillsPtr != "" {
+			levels, err := parseNameList("skills", nil, *skillsPtr, gamedata.GeneralSkillCount)
+			if err != nil {
+				return err
+			}
+			for skill, level := range levels {
+				if err := session.SetGeneralSkill(unitIndex, skill, level); err != nil {
+					return err
+				}
+			}
+			fmt.Println("Set skills of unit", unitIndex, "to", levels)
+		}
+		if *skillPtr != "" {
+			skill, err := gamedata.GeneralSkills.Lookup(*skillPtr)
+			if err != nil {
+				return err
+			}
+			if err := session.SetGeneralSkill(unitIndex, skill, *levelPtr); err != nil {
+				return err
+			}
+			fmt.Println("Set skill", gamedata.GeneralSkills.Format(skill), "of unit", unitIndex, "to level", *levelPtr)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+)
+
+func init() {
+	registerCommand(newMapRenderCommand())
+}
+
+func newMapRenderCommand() *command {
+	cmd := newCommand("map render", "Draw tile ownership, cities and units to a PNG image.")
+	cmd.aliases = []string{"render-map"}
+	save := addSaveFlags(cmd.flags)
+	outputPtr := cmd.flags.String("output", "map.png", "PNG file to write")
+	tileSizePtr := cmd.flags.Int("tile", 16, "tile size in pixels")
+	gridPtr := cmd.flags.Bool("grid", false, "draw grid lines with row and column numbers")
+	unitsPtr := cmd.flags.Bool("units", true, "draw unit markers, generals are marked with G")
+
+	cmd.run = func() error {
+		if *tileSizePtr < 4 || *tileSizePtr > 128 {
+			return fmt.Errorf("-tile must be between 4 and 128, got %v", *tileSizePtr)
+		}
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		mapImage := RenderMap(saveOutput, MapRenderOptions{
+			TileSize:  *tileSizePtr,
+			ShowGrid:  *gridPtr,
+			ShowUnits: *unitsPtr,
+		})
+
+		outputFile, err := os.Create(*outputPtr)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(outputFile, mapImage); err != nil {
+			outputFile.Close()
+			return err
+		}
+		if err := outputFile.Close(); err != nil {
+			return fmt.Errorf("failed to write %v: %w", *outputPtr, err)
+		}
+		fmt.Println("Saved map to", *outputPtr)
+		return nil
+	}
+	return cmd
+}
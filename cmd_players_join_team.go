@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newPlayersJoinTeamCommand())
+}
+
+func newPlayersJoinTeamCommand() *command {
+	cmd := newCommand("players join-team", "Move every other player onto the same team as a player.")
+	cmd.aliases = []string{"convert-team"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index whose team everyone joins")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		if err := checkPlayerIndex(saveOutput, "player", *playerPtr); err != nil {
+			return err
+		}
+
+		playerTeamId := saveOutput.PlayerData[*playerPtr].TeamId
+		for i := 0; i < len(saveOutput.PlayerData); i++ {
+			if i == *playerPtr {
+				continue
+			}
+			fmt.Println("Converting player", i, "from team", saveOutput.PlayerData[i].TeamId, "to team", playerTeamId)
+			if err := session.SetPlayerTeam(i, int(playerTeamId)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newPlayersListCommand())
+}
+
+func newPlayersListCommand() *command {
+	cmd := newCommand("players list", "List every player with country, team and number of units owned.")
+	cmd.aliases = []string{"list-players"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		countMap := make(map[byte]int)
+		for i := 0; i < len(saveOutput.UnitOwnerData); i++ {
+			for j := 0; j < len(saveOutput.UnitOwnerData[i]); j++ {
+				if saveOutput.UnitOwnerData[i][j] == 255 {
+					continue
+				}
+				countMap[saveOutput.UnitOwnerData[i][j]] += 1
+			}
+		}
+
+		for i := 0; i < len(saveOutput.PlayerData); i++ {
+			player := saveOutput.PlayerData[i]
+			fmt.Println(fmt.Sprintf("Player %v: CountryId %v, TeamId %v, units owned: %v", i, gamedata.Countries.Format(int(player.CountryId)), player.TeamId, countMap[byte(i)]))
+		}
+		return nil
+	}
+	return cmd
+}
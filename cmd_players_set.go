@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newPlayersSetCommand())
+}
+
+func newPlayersSetCommand() *command {
+	cmd := newCommand("players set", "Set any field of a player record, e.g. -index 0 -field Currency[0] -value 9999.")
+	cmd.aliases = []string{"set-player"}
+	addWriteSaveFlags(cmd)
+	fieldFlags := addRecordFieldFlags(cmd.flags, fileio.CountryData{})
+
+	cmd.edit = func(session *fileio.Session) error {
+		index, fieldName, value, err := fieldFlags.parse(len(session.Save.PlayerData))
+		if err != nil {
+			return err
+		}
+		oldValue, err := session.SetPlayerField(index, fieldName, value)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Set player %v %v from %v to %v\n", index, fieldName, oldValue, value)
+		return nil
+	}
+	return cmd
+}
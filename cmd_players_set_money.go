@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newPlayersSetMoneyCommand())
+}
+
+func newPlayersSetMoneyCommand() *command {
+	cmd := newCommand("players set-money", "Set all three currencies of a player.")
+	cmd.aliases = []string{"player set-money", "max-money"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index")
+	amountPtr := cmd.flags.Int("amount", 9999, "new amount for every currency")
+
+	cmd.edit = func(session *fileio.Session) error {
+		player := *playerPtr
+		if err := checkPlayerIndex(session.Save, "player", player); err != nil {
+			return err
+		}
+		if *amountPtr < 0 || int64(*amountPtr) > math.MaxUint32 {
+			return fmt.Errorf("-amount must be between 0 and %v, got %v", uint32(math.MaxUint32), *amountPtr)
+		}
+
+		for currencyIndex := 0; currencyIndex < fileio.CurrencyCount; currencyIndex++ {
+			if err := session.SetCurrency(player, currencyIndex, *amountPtr); err != nil {
+				return err
+			}
+		}
+		fmt.Println("Set currency to", *amountPtr, "for player", player)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newSaveApplyJsonCommand())
+}
+
+func newSaveApplyJsonCommand() *command {
+	cmd := newCommand("save apply-json", "Write an edited dump-json file back to the save. YAML files with the same keys are also accepted.")
+	cmd.aliases = []string{"apply-json"}
+	addWriteSaveFlags(cmd)
+	jsonPtr := cmd.flags.String("json", "", "edited JSON or YAML dump (required)")
+
+	cmd.edit = func(session *fileio.Session) error {
+		if *jsonPtr == "" {
+			return fmt.Errorf("missing required flag -json")
+		}
+		saveDump, err := ReadSaveDump(*jsonPtr)
+		if err != nil {
+			return err
+		}
+		changes, err := ApplySaveDump(session.Save, saveDump)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		if len(changes) == 0 {
+			fmt.Println("No changes to apply")
+			return nil
+		}
+		fmt.Println("Applied", len(changes), "changes")
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newSaveDiffCommand())
+}
+
+func newSaveDiffCommand() *command {
+	cmd := newCommand("save diff", "Compare two saves field by field, including the bytes that changed in unknown blocks.")
+	cmd.aliases = []string{"diff"}
+	oldPtr := cmd.flags.String("a", "", "old save file (required)")
+	newPtr := cmd.flags.String("b", "", "new save file (required)")
+
+	cmd.run = func() error {
+		if *oldPtr == "" || *newPtr == "" {
+			return fmt.Errorf("missing required flags -a and -b")
+		}
+		oldSave, err := fileio.ReadSaveFile(*oldPtr)
+		if err != nil {
+			return fmt.Errorf("%v: %w", *oldPtr, err)
+		}
+		newSave, err := fileio.ReadSaveFile(*newPtr)
+		if err != nil {
+			return fmt.Errorf("%v: %w", *newPtr, err)
+		}
+
+		changes := DiffSaves(oldSave, newSave)
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		if len(changes) == 0 {
+			fmt.Println("No differences")
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+)
+
+func init() {
+	registerCommand(newSaveDumpJsonCommand())
+}
+
+func newSaveDumpJsonCommand() *command {
+	cmd := newCommand("save dump-json", "Print the whole save as JSON with coordinates converted to rows and columns.")
+	cmd.aliases = []string{"dump-json"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+		return WriteSaveDumpJson(os.Stdout, saveOutput)
+	}
+	return cmd
+}
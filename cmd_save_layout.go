@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+const maxLayoutBytes = 32
+
+func init() {
+	registerCommand(newSaveLayoutCommand())
+}
+
+// formatLayoutValue prints byte arrays as hex, cut off after maxLayoutBytes bytes
+func formatLayoutValue(value reflect.Value) string {
+	if value.Kind() != reflect.Array || value.Type().Elem().Kind() != reflect.Uint8 {
+		return fmt.Sprint(value.Interface())
+	}
+	byteData := make([]byte, value.Len())
+	reflect.Copy(reflect.ValueOf(byteData), value)
+	if len(byteData) > maxLayoutBytes {
+		return fmt.Sprintf("% x ...", byteData[:maxLayoutBytes])
+	}
+	return fmt.Sprintf("% x", byteData)
+}
+
+func newSaveLayoutCommand() *command {
+	cmd := newCommand("save layout", "Print the offset, size and value of every section and record field. Unknown regions are marked with ?.")
+	cmd.aliases = []string{"layout"}
+	save := addSaveFlags(cmd.flags)
+	sectionPtr := cmd.flags.String("section", "", "only print sections whose name contains this text, e.g. units")
+	unknownPtr := cmd.flags.Bool("unknown", false, "only print unknown sections and fields")
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		for _, section := range fileio.GetSaveLayout(saveOutput) {
+			if !strings.Contains(section.Name, strings.ToLower(*sectionPtr)) {
+				continue
+			}
+			unknownMarker := " "
+			if section.Unknown {
+				unknownMarker = "?"
+			}
+			hasUnknownFields := section.Unknown
+			for _, record := range section.Records {
+				for _, field := range fileio.GetRecordFields(record.Record) {
+					hasUnknownFields = hasUnknownFields || strings.HasPrefix(field.Name, "Unknown")
+				}
+			}
+			if *unknownPtr && !hasUnknownFields {
+				continue
+			}
+
+			fmt.Printf("0x%08x-0x%08x %v %v (%v bytes)\n", section.Start, section.End, unknownMarker, section.Name, section.End-section.Start)
+			for _, record := range section.Records {
+				fmt.Printf("  0x%08x %v\n", record.Start, record.Name)
+				for _, field := range fileio.GetRecordFields(record.Record) {
+					isUnknown := section.Unknown || strings.HasPrefix(field.Name, "Unknown")
+					if *unknownPtr && !isUnknown {
+						continue
+					}
+					fieldMarker := " "
+					if isUnknown {
+						fieldMarker = "?"
+					}
+					fmt.Printf("    0x%08x +%-4v %4v %v %-20v %v\n", record.Start+field.Offset, field.Offset, field.Size, fieldMarker,
+						field.Name, formatLayoutValue(field.GetValue(record.Record)))
+				}
+			}
+		}
+		return nil
+	}
+	return cmd
+}
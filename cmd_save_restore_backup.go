@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newSaveRestoreBackupCommand())
+}
+
+func newSaveRestoreBackupCommand() *command {
+	cmd := newCommand("save restore-backup", "Roll the save back to a backup made before a write command.")
+	cmd.aliases = []string{"restore-backup"}
+	inputPtr := cmd.flags.String("input", "", "save file to restore (required)")
+	backupPtr := cmd.flags.String("backup", "", "backup to restore (default: the newest backup of the save)")
+	listPtr := cmd.flags.Bool("list", false, "list the backups of the save, newest first, without restoring")
+
+	cmd.run = func() error {
+		if *inputPtr == "" {
+			return fmt.Errorf("missing required flag -input")
+		}
+
+		backups, err := fileio.FindBackups(*inputPtr)
+		if err != nil {
+			return err
+		}
+		if *listPtr {
+			for _, backup := range backups {
+				fmt.Println(backup)
+			}
+			return nil
+		}
+
+		backupFilename := *backupPtr
+		if backupFilename == "" {
+			if len(backups) == 0 {
+				return fmt.Errorf("no backups found for %v", *inputPtr)
+			}
+			backupFilename = backups[0]
+		}
+		if err := fileio.RestoreBackup(*inputPtr, backupFilename); err != nil {
+			return err
+		}
+		fmt.Println("Restored", *inputPtr, "from", backupFilename)
+		return nil
+	}
+	return cmd
+}
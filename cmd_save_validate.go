@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newSaveValidateCommand())
+}
+
+func newSaveValidateCommand() *command {
+	cmd := newCommand("save validate", "Check the save for out of range coordinates, owners, health and other broken invariants.")
+	cmd.aliases = []string{"validate"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		issues := fileio.ValidateSave(saveOutput)
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("found %v problems in %v", len(issues), *save.input)
+		}
+		fmt.Println("No problems found in", *save.input)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newSaveVerifyRoundTripCommand())
+}
+
+func newSaveVerifyRoundTripCommand() *command {
+	cmd := newCommand("save verify-roundtrip", "Check that the save can be parsed and written back byte for byte.")
+	cmd.aliases = []string{"verify-roundtrip"}
+	inputPtr := cmd.flags.String("input", "", "save file to read (required)")
+
+	cmd.run = func() error {
+		if *inputPtr == "" {
+			return fmt.Errorf("missing required flag -input")
+		}
+		if err := fileio.VerifyRoundTrip(*inputPtr); err != nil {
+			return err
+		}
+		fmt.Println("Save file can be written back without changes")
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newTilesConvertAllCommand())
+}
+
+func newTilesConvertAllCommand() *command {
+	cmd := newCommand("tiles convert-all", "Give every unit, city and landmine on the map to one player.")
+	cmd.aliases = []string{"convert-all-players"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index that receives every tile")
+	forcePtr := addForceTransferFlag(cmd)
+
+	cmd.edit = func(session *fileio.Session) error {
+		session.AllowStaleImportantCities = *forcePtr
+		saveOutput := session.Save
+		player := *playerPtr
+		if err := checkPlayerIndex(saveOutput, "player", player); err != nil {
+			return err
+		}
+
+		totalChanges := fileio.OwnershipChanges{}
+		for i := 0; i < len(saveOutput.PlayerData); i++ {
+			if i == player {
+				continue
+			}
+			changes, err := session.TransferPlayer(i, player)
+			if err != nil {
+				return explainTransferError(err)
+			}
+			fmt.Println("Converted player", i, "to player", player, "-", changes)
+			totalChanges.Add(changes)
+		}
+		fmt.Println("Converted all players. Changed", totalChanges)
+		return nil
+	}
+	return cmd
+}
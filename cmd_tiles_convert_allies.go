@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newTilesConvertAlliesCommand())
+}
+
+func newTilesConvertAlliesCommand() *command {
+	cmd := newCommand("tiles convert-allies", "Give every unit, city and landmine of a player's allies to that player.")
+	cmd.aliases = []string{"convert-all-allies"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index that receives the allied tiles")
+	forcePtr := addForceTransferFlag(cmd)
+
+	cmd.edit = func(session *fileio.Session) error {
+		session.AllowStaleImportantCities = *forcePtr
+		saveOutput := session.Save
+		player := *playerPtr
+		if err := checkPlayerIndex(saveOutput, "player", player); err != nil {
+			return err
+		}
+		playerTeamId := saveOutput.PlayerData[player].TeamId
+
+		totalChanges := fileio.OwnershipChanges{}
+		for i := 0; i < len(saveOutput.PlayerData); i++ {
+			if i == player || saveOutput.PlayerData[i].TeamId != playerTeamId {
+				continue
+			}
+			changes, err := session.TransferPlayer(i, player)
+			if err != nil {
+				return explainTransferError(err)
+			}
+			fmt.Println("Converted ally", i, "to player", player, "-", changes)
+			totalChanges.Add(changes)
+		}
+		fmt.Println("Converted all allies. Changed", totalChanges)
+		return nil
+	}
+	return cmd
+}
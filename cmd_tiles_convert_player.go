@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newTilesConvertPlayerCommand())
+}
+
+func newTilesConvertPlayerCommand() *command {
+	cmd := newCommand("tiles convert-player", "Give every unit, city and landmine of one player to another player.")
+	cmd.aliases = []string{"convert-player"}
+	addWriteSaveFlags(cmd)
+	fromPtr := cmd.flags.Int("from", -1, "player index that currently owns the tiles (required)")
+	toPtr := cmd.flags.Int("to", 0, "player index that receives the tiles")
+	forcePtr := addForceTransferFlag(cmd)
+
+	cmd.edit = func(session *fileio.Session) error {
+		session.AllowStaleImportantCities = *forcePtr
+		oldPlayer := *fromPtr
+		newPlayer := *toPtr
+		if err := checkPlayerIndex(session.Save, "from", oldPlayer); err != nil {
+			return err
+		}
+		if err := checkPlayerIndex(session.Save, "to", newPlayer); err != nil {
+			return err
+		}
+
+		changes, err := session.TransferPlayer(oldPlayer, newPlayer)
+		if err != nil {
+			return explainTransferError(err)
+		}
+		fmt.Println("Gave player", newPlayer, changes, "from player", oldPlayer)
+		return nil
+	}
+	return cmd
+}
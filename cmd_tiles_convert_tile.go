@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newTilesConvertTileCommand())
+}
+
+func newTilesConvertTileCommand() *command {
+	cmd := newCommand("tiles convert-tile", "Give the unit or city on one tile to another player.")
+	cmd.aliases = []string{"convert-tile"}
+	addWriteSaveFlags(cmd)
+	xPtr := cmd.flags.Int("x", -1, "tile column (required)")
+	yPtr := cmd.flags.Int("y", -1, "tile row (required)")
+	ownerPtr := cmd.flags.Int("owner", 0, "player index that receives the tile")
+	forcePtr := addForceTransferFlag(cmd)
+
+	cmd.edit = func(session *fileio.Session) error {
+		session.AllowStaleImportantCities = *forcePtr
+		saveOutput := session.Save
+		targetX := *xPtr
+		targetY := *yPtr
+		newPlayer := *ownerPtr
+		if err := checkTile(saveOutput, targetX, targetY); err != nil {
+			return err
+		}
+		if err := checkPlayerIndex(saveOutput, "owner", newPlayer); err != nil {
+			return err
+		}
+
+		oldPlayer := saveOutput.UnitOwnerData[targetY][targetX]
+		if oldPlayer == fileio.NoTileOwner {
+			return fmt.Errorf("Can't convert tile at (%v, %v) without owner. Row: %v", targetY, targetX, saveOutput.UnitOwnerData[targetY])
+		}
+		changes, err := session.TransferTile(targetX, targetY, newPlayer)
+		if err != nil {
+			return explainTransferError(err)
+		}
+		fmt.Println(fmt.Sprintf("Changed owner at (%v, %v) from %v to %v: %v", targetY, targetX, oldPlayer, newPlayer, changes))
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+)
+
+func init() {
+	registerCommand(newTilesListCommand())
+}
+
+func newTilesListCommand() *command {
+	cmd := newCommand("tiles list", "List every tile where the given player owns a unit.")
+	cmd.aliases = []string{"list-player-tiles"}
+	save := addSaveFlags(cmd.flags)
+	playerPtr := cmd.flags.Int("player", 0, "player index")
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+		player := *playerPtr
+		if err := checkPlayerIndex(saveOutput, "player", player); err != nil {
+			return err
+		}
+
+		for i := 0; i < len(saveOutput.UnitOwnerData); i++ {
+			for j := 0; j < len(saveOutput.UnitOwnerData[i]); j++ {
+				if saveOutput.UnitOwnerData[i][j] != byte(player) {
+					continue
+				}
+
+				fmt.Println(fmt.Sprintf("Player %v owns unit at tile (%v, %v)", player, i, j))
+			}
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newUnitsAddCommand())
+}
+
+func newUnitsAddCommand() *command {
+	cmd := newCommand("units add", "Add a new unit on a free tile.")
+	cmd.aliases = []string{"add-unit"}
+	addWriteSaveFlags(cmd)
+	typePtr := cmd.flags.String("type", "", "unit type number or name (required)")
+	xPtr := cmd.flags.Int("x", -1, "tile column (required)")
+	yPtr := cmd.flags.Int("y", -1, "tile row (required)")
+	ownerPtr := cmd.flags.Int("owner", 0, "player index that owns the unit")
+	healthPtr := cmd.flags.Int("health", 0, "max health of the unit (default: copied from a unit of the same type in the save)")
+	levelPtr := cmd.flags.Int("level", 0, "unit level (default: copied from a unit of the same type in the save)")
+
+	cmd.edit = func(session *fileio.Session) error {
+		if *typePtr == "" {
+			return fmt.Errorf("missing required flag -type")
+		}
+		unitType, err := gamedata.UnitTypes.Lookup(*typePtr)
+		if err != nil {
+			return err
+		}
+		if err := checkPlayerIndex(session.Save, "owner", *ownerPtr); err != nil {
+			return err
+		}
+
+		index, err := session.AddUnit(fileio.NewUnit{
+			UnitType:  unitType,
+			X:         *xPtr,
+			Y:         *yPtr,
+			Owner:     *ownerPtr,
+			MaxHealth: *healthPtr,
+			Level:     *levelPtr,
+		})
+		if err != nil {
+			return err
+		}
+		unit := session.Save.Units[index]
+		fmt.Printf("Added unit %v of type %v for player %v at (x: %v, y: %v) with %v health\n",
+			index, gamedata.UnitTypes.Format(unitType), *ownerPtr, *xPtr, *yPtr, unit.MaxHealth)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newUnitsListCommand())
+}
+
+func newUnitsListCommand() *command {
+	cmd := newCommand("units list", "List every unit with its owner and location.")
+	cmd.aliases = []string{"list-units"}
+	save := addSaveFlags(cmd.flags)
+
+	cmd.run = func() error {
+		saveOutput, err := save.load()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(saveOutput.Units); i++ {
+			unit := saveOutput.Units[i]
+			row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+			fmt.Printf("Unit %v (type: %v, owner: %v, row: %v, column: %v): %+v\n", i, gamedata.UnitTypes.Format(int(unit.UnitType)),
+				fileio.GetTileOwner(saveOutput, row, col), row, col, unit)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newUnitsMoveCommand())
+}
+
+func newUnitsMoveCommand() *command {
+	cmd := newCommand("units move", "Move a unit to a free tile.")
+	cmd.aliases = []string{"move-unit"}
+	addWriteSaveFlags(cmd)
+	indexPtr := cmd.flags.Int("index", -1, "index of the unit to move (required)")
+	xPtr := cmd.flags.Int("x", -1, "destination column (required)")
+	yPtr := cmd.flags.Int("y", -1, "destination row (required)")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		if *indexPtr < 0 || *indexPtr >= len(saveOutput.Units) {
+			return fmt.Errorf("-index must be between 0 and %v, got %v", len(saveOutput.Units)-1, *indexPtr)
+		}
+		if err := checkTile(saveOutput, *xPtr, *yPtr); err != nil {
+			return err
+		}
+
+		unit := saveOutput.Units[*indexPtr]
+		oldRow, oldCol := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+		if err := session.MoveUnit(*indexPtr, *xPtr, *yPtr); err != nil {
+			return err
+		}
+		fmt.Printf("Moved unit %v from (x: %v, y: %v) to (x: %v, y: %v)\n", *indexPtr, oldCol, oldRow, *xPtr, *yPtr)
+		return nil
+	}
+	return cmd
+}
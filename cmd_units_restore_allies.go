@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newUnitsRestoreAlliesCommand())
+}
+
+func newUnitsRestoreAlliesCommand() *command {
+	cmd := newCommand("units restore-allies", "Heal every unit on the same team as a player to max health.")
+	cmd.aliases = []string{"restore-allies"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index whose team is healed")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		if err := checkPlayerIndex(saveOutput, "player", *playerPtr); err != nil {
+			return err
+		}
+		healedUnits, err := session.RestoreAllies(*playerPtr)
+		if err != nil {
+			return err
+		}
+		for _, unitIndex := range healedUnits {
+			fmt.Println("Restore unit", unitIndex, "health to", saveOutput.Units[unitIndex].MaxHealth)
+		}
+		fmt.Println("Restored allies. Changed", len(healedUnits), "units to have max health.")
+		return nil
+	}
+	return cmd
+}
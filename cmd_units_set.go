@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func init() {
+	registerCommand(newUnitsSetCommand())
+}
+
+func newUnitsSetCommand() *command {
+	cmd := newCommand("units set", "Set any field of a unit record, e.g. -index 0 -field Experience -value 500.")
+	cmd.aliases = []string{"set-unit"}
+	addWriteSaveFlags(cmd)
+	fieldFlags := addRecordFieldFlags(cmd.flags, fileio.UnitData{})
+
+	cmd.edit = func(session *fileio.Session) error {
+		index, fieldName, value, err := fieldFlags.parse(len(session.Save.Units))
+		if err != nil {
+			return err
+		}
+		oldValue, err := session.SetUnitField(index, fieldName, value)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Set unit %v %v from %v to %v\n", index, fieldName, oldValue, value)
+		return nil
+	}
+	return cmd
+}
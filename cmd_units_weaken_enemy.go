@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func init() {
+	registerCommand(newUnitsWeakenEnemyCommand())
+}
+
+func newUnitsWeakenEnemyCommand() *command {
+	cmd := newCommand("units weaken-enemy", "Reduce every enemy unit to 1 health and every enemy city to 0 health.")
+	cmd.aliases = []string{"weaken-enemy"}
+	addWriteSaveFlags(cmd)
+	playerPtr := cmd.flags.Int("player", 0, "player index whose enemies are weakened")
+
+	cmd.edit = func(session *fileio.Session) error {
+		saveOutput := session.Save
+		if err := checkPlayerIndex(saveOutput, "player", *playerPtr); err != nil {
+			return err
+		}
+		playerTeamId := saveOutput.PlayerData[*playerPtr].TeamId
+
+		fmt.Println("Current player teamId", playerTeamId)
+
+		unitCount, cityCount := 0, 0
+		for i := 0; i < len(saveOutput.Units); i++ {
+			unit := saveOutput.Units[i]
+			row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+			owner := fileio.GetTileOwner(saveOutput, row, col)
+
+			if owner < 0 || owner >= len(saveOutput.PlayerData) {
+				fmt.Println("Invalid owner", owner, ", skip")
+				continue
+			}
+
+			if saveOutput.PlayerData[owner].TeamId != playerTeamId {
+				if unit.UnitType == gamedata.CityUnitType {
+					if unit.CurrentHealth == 0 {
+						continue
+					}
+					fmt.Println("Reduce enemy city", i, "health to 0")
+					if err := session.SetUnitHealth(i, 0); err != nil {
+						return err
+					}
+					cityCount += 1
+				} else if unit.CurrentHealth > 1 {
+					fmt.Println("Reduce enemy unit", i, "health to 1")
+					if err := session.SetUnitHealth(i, 1); err != nil {
+						return err
+					}
+					unitCount += 1
+				}
+			}
+		}
+		fmt.Println("Weakened enemies. Changed", unitCount, "units to have 1 health and", cityCount, "cities to have 0 health.")
+		return nil
+	}
+	return cmd
+}
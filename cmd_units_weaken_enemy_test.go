@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+// runEdit runs a write command on a session and returns what it printed
+func runEdit(t *testing.T, cmd *command, session *fileio.Session, args ...string) string {
+	t.Helper()
+	if err := cmd.flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	editErr := cmd.edit(session)
+	os.Stdout = stdout
+	writer.Close()
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if editErr != nil {
+		t.Fatal(editErr)
+	}
+	return string(output)
+}
+
+func TestWeakenEnemyCountsChangedRecords(t *testing.T) {
+	session := openTestSave(t, "conquest.sav")
+
+	// player 1 is player 0's only enemy, with unit 1 and the city unit 3
+	output := runEdit(t, newUnitsWeakenEnemyCommand(), session, "-player", "0")
+	if !strings.Contains(output, "Changed 1 units to have 1 health and 1 cities to have 0 health") {
+		t.Errorf("unexpected output %q", output)
+	}
+	if session.Save.Units[1].CurrentHealth != 1 || session.Save.Units[3].CurrentHealth != 0 {
+		t.Errorf("expected health 1 and 0, got %v and %v", session.Save.Units[1].CurrentHealth, session.Save.Units[3].CurrentHealth)
+	}
+
+	output = runEdit(t, newUnitsWeakenEnemyCommand(), session, "-player", "0")
+	if !strings.Contains(output, "Changed 0 units to have 1 health and 0 cities to have 0 health") {
+		t.Errorf("weakening again should change nothing, got %q", output)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+// command is a single subcommand such as "units list". Every command owns its
+// flag set, so flags are typed and documented per command.
+type command struct {
+	name    string
+	aliases []string
+	summary string
+	flags   *flag.FlagSet
+	run     func() error
+	// edit is set instead of run for commands that modify the save, see addWriteSaveFlags
+	edit      func(session *fileio.Session) error
+	writeSave *saveFlags
+}
+
+var commands = make([]*command, 0)
+
+func newCommand(name string, summary string) *command {
+	cmd := &command{
+		name:    name,
+		summary: summary,
+		flags:   flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.flags.Usage = func() {
+		output := cmd.flags.Output()
+		fmt.Fprintf(output, "Usage: %v %v [flags]\n\n%v\n", programName(), cmd.name, cmd.summary)
+		if len(cmd.aliases) > 0 {
+			fmt.Fprintf(output, "\nAliases: %v\n", strings.Join(cmd.aliases, ", "))
+		}
+		fmt.Fprintln(output, "\nFlags:")
+		cmd.flags.PrintDefaults()
+	}
+	return cmd
+}
+
+func registerCommand(cmd *command) {
+	commands = append(commands, cmd)
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+func (cmd *command) matches(name string) bool {
+	if cmd.name == name {
+		return true
+	}
+	for _, alias := range cmd.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findCommand matches the longest command name at the start of args
+// and returns the command with the remaining arguments
+func findCommand(args []string) (*command, []string) {
+	for wordCount := 2; wordCount >= 1; wordCount-- {
+		if len(args) < wordCount {
+			continue
+		}
+		name := strings.Join(args[:wordCount], " ")
+		for _, cmd := range commands {
+			if cmd.matches(name) {
+				return cmd, args[wordCount:]
+			}
+		}
+	}
+	return nil, args
+}
+
+func printUsage(output io.Writer, group string) {
+	sortedCommands := make([]*command, len(commands))
+	copy(sortedCommands, commands)
+	sort.Slice(sortedCommands, func(i, j int) bool {
+		return sortedCommands[i].name < sortedCommands[j].name
+	})
+
+	fmt.Fprintf(output, "Usage: %v <command> [flags]\n\nCommands:\n", programName())
+	for _, cmd := range sortedCommands {
+		if group != "" && !strings.HasPrefix(cmd.name, group+" ") {
+			continue
+		}
+		fmt.Fprintf(output, "  %-28v %v\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(output, "\nRun '%v <command> -help' for the flags of a command.\n", programName())
+}
+
+func isCommandGroup(name string) bool {
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd.name, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func runCommand(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-help" || args[0] == "--help" || args[0] == "-h" {
+		printUsage(os.Stdout, "")
+		return 0
+	}
+
+	if strings.Contains(args[0], ",") {
+		return runCommandChain(args[0], args[1:])
+	}
+
+	cmd, commandArgs := findCommand(args)
+	if cmd == nil {
+		if isCommandGroup(args[0]) {
+			printUsage(os.Stderr, args[0])
+		} else {
+			fmt.Fprintf(os.Stderr, "Unrecognized command: %v\n\n", strings.Join(args, " "))
+			printUsage(os.Stderr, "")
+		}
+		return 2
+	}
+
+	if err := cmd.flags.Parse(commandArgs); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if cmd.flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %v\n\n", strings.Join(cmd.flags.Args(), " "))
+		cmd.flags.Usage()
+		return 2
+	}
+
+	run := cmd.run
+	if cmd.edit != nil {
+		run = func() error { return runEditCommands([]*command{cmd}) }
+	}
+	if err := run(); err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// saveFlags are the flags shared by every command that reads a save file
+type saveFlags struct {
+	input    *string
+	verbose  *bool
+	names    *string
+	dryRun   *bool
+	noBackup *bool
+}
+
+func addSaveFlags(flags *flag.FlagSet) *saveFlags {
+	return &saveFlags{
+		input:   flags.String("input", "", "save file to read (required)"),
+		verbose: flags.Bool("verbose", false, "print every record while parsing the save file"),
+		names:   flags.String("names", "", "JSON or YAML file with more unit type, country, general and wonder names"),
+	}
+}
+
+// addWriteSaveFlags adds the save flags for a command that modifies the save.
+// The command sets edit instead of run and makes its changes to an edit session.
+func addWriteSaveFlags(cmd *command) *saveFlags {
+	save := addSaveFlags(cmd.flags)
+	save.dryRun = cmd.flags.Bool("dry-run", false, "report the byte ranges that would change without modifying the save")
+	save.noBackup = cmd.flags.Bool("no-backup", false, "do not back up the save before modifying it")
+	cmd.writeSave = save
+	return save
+}
+
+// addForceTransferFlag adds -force to the commands that can hand cities to another player
+func addForceTransferFlag(cmd *command) *bool {
+	return cmd.flags.Bool("force", false, "transfer cities even though the save's important city records, which are not decoded yet, keep naming the old owner")
+}
+
+// explainTransferError tells how to transfer cities that were refused anyway
+func explainTransferError(err error) error {
+	if errors.Is(err, fileio.ErrImportantCitiesNotDecoded) {
+		return fmt.Errorf("%w, pass -force to transfer anyway", err)
+	}
+	return err
+}
+
+func (s *saveFlags) readOptions() ([]fileio.ReadOption, error) {
+	if *s.input == "" {
+		return nil, fmt.Errorf("missing required flag -input")
+	}
+	if *s.names != "" {
+		if err := gamedata.LoadNames(*s.names); err != nil {
+			return nil, err
+		}
+	}
+
+	readOptions := make([]fileio.ReadOption, 0)
+	if *s.verbose {
+		readOptions = append(readOptions, fileio.WithLogger(log.New(os.Stdout, "", 0)))
+	}
+	return readOptions, nil
+}
+
+func (s *saveFlags) load() (*fileio.WC4SaveOutput, error) {
+	readOptions, err := s.readOptions()
+	if err != nil {
+		return nil, err
+	}
+	return fileio.ReadSaveFile(*s.input, readOptions...)
+}
+
+func (s *saveFlags) open() (*fileio.Session, error) {
+	readOptions, err := s.readOptions()
+	if err != nil {
+		return nil, err
+	}
+	return fileio.Open(*s.input, readOptions...)
+}
+
+// optionalInt is an int flag that remembers whether it was given, so that any value,
+// -1 included, is checked instead of being taken to mean the flag is not set.
+// It keeps working in chains, which set the flag values directly.
+type optionalInt struct {
+	value int
+	set   bool
+}
+
+func (v *optionalInt) String() string {
+	if !v.set {
+		return ""
+	}
+	return strconv.Itoa(v.value)
+}
+
+func (v *optionalInt) Set(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	v.value = n
+	v.set = true
+	return nil
+}
+
+// optionalString is a string flag that remembers whether it was given, even if empty
+type optionalString struct {
+	value string
+	set   bool
+}
+
+func (v *optionalString) String() string {
+	return v.value
+}
+
+func (v *optionalString) Set(value string) error {
+	v.value = value
+	v.set = true
+	return nil
+}
+
+func checkPlayerIndex(saveOutput *fileio.WC4SaveOutput, flagName string, player int) error {
+	if player < 0 || player >= len(saveOutput.PlayerData) {
+		return fmt.Errorf("-%v must be a player index between 0 and %v, got %v", flagName, len(saveOutput.PlayerData)-1, player)
+	}
+	return nil
+}
+
+func checkTile(saveOutput *fileio.WC4SaveOutput, x int, y int) error {
+	if y < 0 || y >= len(saveOutput.UnitOwnerData) || x < 0 || x >= len(saveOutput.UnitOwnerData[y]) {
+		return fmt.Errorf("tile (x: %v, y: %v) is outside the %vx%v map", x, y, saveOutput.SaveHeader.MapWidth, saveOutput.SaveHeader.MapHeight)
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+// DiffSaves lists the differences between two saves, such as the same game saved before and
+// after one action. Records are compared field by field, and byte arrays and unknown blocks
+// are reported as the byte ranges that changed.
+func DiffSaves(oldSave *fileio.WC4SaveOutput, newSave *fileio.WC4SaveOutput) []string {
+	changes := make([]string, 0)
+	changes = append(changes, diffRecords("Header", oldSave.SaveHeader, newSave.SaveHeader)...)
+	changes = append(changes, diffRecordLists("Player", oldSave.PlayerData, newSave.PlayerData)...)
+	changes = append(changes, diffTileOwners(oldSave, newSave)...)
+	changes = append(changes, diffCityTiles(oldSave, newSave)...)
+	changes = append(changes, diffRecordLists("City", oldSave.Cities, newSave.Cities)...)
+	changes = append(changes, diffUnits(oldSave, newSave)...)
+	changes = append(changes, diffRecordLists("Landmine", oldSave.Landmines, newSave.Landmines)...)
+
+	unknownBlocks := []struct {
+		name    string
+		oldData interface{}
+		newData interface{}
+	}{
+		{"CampaignTiles", oldSave.CampaignTiles, newSave.CampaignTiles},
+		{"UnknownData2", oldSave.UnknownData2, newSave.UnknownData2},
+		{"UnknownData3", oldSave.UnknownData3, newSave.UnknownData3},
+		{"UnknownData4", oldSave.UnknownData4, newSave.UnknownData4},
+		{"UnknownData5", oldSave.UnknownData5, newSave.UnknownData5},
+		{"UnknownData6", oldSave.UnknownData6, newSave.UnknownData6},
+		{"ImportantCities", oldSave.ImportantCities, newSave.ImportantCities},
+		{"UnknownData7", oldSave.UnknownData7, newSave.UnknownData7},
+		{"TrailingData", oldSave.TrailingData, newSave.TrailingData},
+	}
+	for _, block := range unknownBlocks {
+		changes = append(changes, diffBlockBytes(block.name, block.oldData, block.newData)...)
+	}
+	return changes
+}
+
+func describeByteRange(oldData []byte, newData []byte, byteRange fileio.ByteRange) string {
+	oldBytes := []byte{}
+	if byteRange.Start < len(oldData) {
+		oldBytes = oldData[byteRange.Start:minInt(byteRange.End, len(oldData))]
+	}
+	newBytes := []byte{}
+	if byteRange.Start < len(newData) {
+		newBytes = newData[byteRange.Start:minInt(byteRange.End, len(newData))]
+	}
+	return fmt.Sprintf("% x -> % x", oldBytes, newBytes)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// diffRecords compares two records of the same type. Byte arrays, which hold most
+// of the unknown fields, are compared byte by byte with offsets into the record.
+func diffRecords(prefix string, oldRecord interface{}, newRecord interface{}) []string {
+	changes := make([]string, 0)
+	for _, field := range fileio.GetRecordFields(oldRecord) {
+		oldField := field.GetValue(oldRecord)
+		newField := field.GetValue(newRecord)
+		if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			continue
+		}
+
+		isByteArray := field.Type.Kind() == reflect.Array && field.Type.Elem().Kind() == reflect.Uint8
+		if !isByteArray {
+			changes = append(changes, fmt.Sprintf("%v %v: %v -> %v", prefix, field.Name, oldField.Interface(), newField.Interface()))
+			continue
+		}
+		oldBytes := make([]byte, field.Size)
+		newBytes := make([]byte, field.Size)
+		reflect.Copy(reflect.ValueOf(oldBytes), oldField)
+		reflect.Copy(reflect.ValueOf(newBytes), newField)
+		for _, byteRange := range fileio.CompareSaveData(oldBytes, newBytes) {
+			changes = append(changes, fmt.Sprintf("%v %v[%v:%v] (record offset %v): %v", prefix, field.Name,
+				byteRange.Start, byteRange.End, field.Offset+byteRange.Start, describeByteRange(oldBytes, newBytes, byteRange)))
+		}
+	}
+	return changes
+}
+
+// diffRecordLists compares records with the same index and reports added or removed records at the end
+func diffRecordLists(prefix string, oldRecords interface{}, newRecords interface{}) []string {
+	changes := make([]string, 0)
+	oldList := reflect.ValueOf(oldRecords)
+	newList := reflect.ValueOf(newRecords)
+	for i := 0; i < oldList.Len() && i < newList.Len(); i++ {
+		changes = append(changes, diffRecords(fmt.Sprintf("%v %v", prefix, i), oldList.Index(i).Interface(), newList.Index(i).Interface())...)
+	}
+	for i := newList.Len(); i < oldList.Len(); i++ {
+		changes = append(changes, fmt.Sprintf("%v %v removed", prefix, i))
+	}
+	for i := oldList.Len(); i < newList.Len(); i++ {
+		changes = append(changes, fmt.Sprintf("%v %v added", prefix, i))
+	}
+	return changes
+}
+
+func formatOwner(owner int) string {
+	if owner == fileio.NoTileOwner {
+		return "none"
+	}
+	return fmt.Sprint(owner)
+}
+
+func diffTileOwners(oldSave *fileio.WC4SaveOutput, newSave *fileio.WC4SaveOutput) []string {
+	changes := make([]string, 0)
+	if len(oldSave.UnitOwnerData) != len(newSave.UnitOwnerData) {
+		return append(changes, "Map size changed, tile owners are not compared")
+	}
+	for row := range oldSave.UnitOwnerData {
+		if len(oldSave.UnitOwnerData[row]) != len(newSave.UnitOwnerData[row]) {
+			return append(changes, "Map size changed, tile owners are not compared")
+		}
+		for col := range oldSave.UnitOwnerData[row] {
+			oldOwner := oldSave.UnitOwnerData[row][col]
+			newOwner := newSave.UnitOwnerData[row][col]
+			if oldOwner != newOwner {
+				changes = append(changes, fmt.Sprintf("Tile (row %v, col %v) owner: %v -> %v", row, col, formatOwner(int(oldOwner)), formatOwner(int(newOwner))))
+			}
+		}
+	}
+	return changes
+}
+
+func diffCityTiles(oldSave *fileio.WC4SaveOutput, newSave *fileio.WC4SaveOutput) []string {
+	changes := make([]string, 0)
+	for row := 0; row < len(oldSave.CityTiles) && row < len(newSave.CityTiles); row++ {
+		for col := 0; col < len(oldSave.CityTiles[row]) && col < len(newSave.CityTiles[row]); col++ {
+			oldCity := oldSave.CityTiles[row][col]
+			newCity := newSave.CityTiles[row][col]
+			if oldCity != newCity {
+				changes = append(changes, fmt.Sprintf("Tile (row %v, col %v) city: %v -> %v", row, col, oldCity, newCity))
+			}
+		}
+	}
+	return changes
+}
+
+// unitOwner returns the owner of the tile a unit stands on
+func unitOwner(saveOutput *fileio.WC4SaveOutput, unit fileio.UnitData) int {
+	row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+	return fileio.GetTileOwner(saveOutput, row, col)
+}
+
+// unitDistance is the number of rows plus columns between the tiles of two units
+func unitDistance(oldSave *fileio.WC4SaveOutput, oldUnit fileio.UnitData, newSave *fileio.WC4SaveOutput, newUnit fileio.UnitData) int {
+	oldRow, oldCol := fileio.ConvertCoordinates(int(oldUnit.CoordinateCode), oldSave.UnitOwnerData, int(oldSave.SaveHeader.GameMode))
+	newRow, newCol := fileio.ConvertCoordinates(int(newUnit.CoordinateCode), newSave.UnitOwnerData, int(newSave.SaveHeader.GameMode))
+	absInt := func(value int) int {
+		if value < 0 {
+			return -value
+		}
+		return value
+	}
+	return absInt(oldRow-newRow) + absInt(oldCol-newCol)
+}
+
+// matchUnits pairs the units of two saves by identity, so a unit that moved, was healed
+// or changed index because another unit was removed is still reported as one unit:
+// identical records first, then units led by the same general, then the nearest unit
+// of the same type and owner, and finally the unit on the same tile. Ties go to the
+// unit at the same or the closest index. The units left over were removed or added.
+// This runs even if the unit count is the same, since one unit may have been removed
+// and another added.
+func matchUnits(oldSave *fileio.WC4SaveOutput, newSave *fileio.WC4SaveOutput) ([][2]int, []int, []int) {
+	oldUnits := oldSave.Units
+	newUnits := newSave.Units
+	pairs := make([][2]int, 0)
+
+	oldMatched := make([]bool, len(oldUnits))
+	newMatched := make([]bool, len(newUnits))
+	matchPair := func(i int, j int) {
+		oldMatched[i] = true
+		newMatched[j] = true
+		pairs = append(pairs, [2]int{i, j})
+	}
+	matchPass := func(isMatch func(oldUnit fileio.UnitData, newUnit fileio.UnitData) bool) {
+		for i, oldUnit := range oldUnits {
+			if oldMatched[i] {
+				continue
+			}
+			if i < len(newUnits) && !newMatched[i] && isMatch(oldUnit, newUnits[i]) {
+				matchPair(i, i)
+				continue
+			}
+			for j, newUnit := range newUnits {
+				if !newMatched[j] && isMatch(oldUnit, newUnit) {
+					matchPair(i, j)
+					break
+				}
+			}
+		}
+	}
+	matchPass(func(oldUnit fileio.UnitData, newUnit fileio.UnitData) bool {
+		return oldUnit == newUnit
+	})
+	matchPass(func(oldUnit fileio.UnitData, newUnit fileio.UnitData) bool {
+		return oldUnit.GeneralId != 0 && oldUnit.GeneralId == newUnit.GeneralId
+	})
+
+	// pair the closest units of the same type and owner first
+	type candidate struct {
+		oldIndex int
+		newIndex int
+		distance int
+	}
+	candidates := make([]candidate, 0)
+	for i, oldUnit := range oldUnits {
+		if oldMatched[i] {
+			continue
+		}
+		oldOwner := unitOwner(oldSave, oldUnit)
+		for j, newUnit := range newUnits {
+			if newMatched[j] || oldUnit.UnitType != newUnit.UnitType || oldOwner != unitOwner(newSave, newUnit) {
+				continue
+			}
+			candidates = append(candidates, candidate{i, j, unitDistance(oldSave, oldUnit, newSave, newUnit)})
+		}
+	}
+	indexDistance := func(c candidate) int {
+		if c.oldIndex > c.newIndex {
+			return c.oldIndex - c.newIndex
+		}
+		return c.newIndex - c.oldIndex
+	}
+	sort.SliceStable(candidates, func(a int, b int) bool {
+		if candidates[a].distance != candidates[b].distance {
+			return candidates[a].distance < candidates[b].distance
+		}
+		return indexDistance(candidates[a]) < indexDistance(candidates[b])
+	})
+	for _, candidate := range candidates {
+		if oldMatched[candidate.oldIndex] || newMatched[candidate.newIndex] {
+			continue
+		}
+		matchPair(candidate.oldIndex, candidate.newIndex)
+	}
+	// a unit that changed hands or type in place still stands on its tile
+	matchPass(func(oldUnit fileio.UnitData, newUnit fileio.UnitData) bool {
+		return oldUnit.CoordinateCode == newUnit.CoordinateCode
+	})
+	sort.Slice(pairs, func(a int, b int) bool {
+		return pairs[a][0] < pairs[b][0]
+	})
+
+	removed := make([]int, 0)
+	for i, matched := range oldMatched {
+		if !matched {
+			removed = append(removed, i)
+		}
+	}
+	added := make([]int, 0)
+	for j, matched := range newMatched {
+		if !matched {
+			added = append(added, j)
+		}
+	}
+	return pairs, removed, added
+}
+
+func describeUnitTile(saveOutput *fileio.WC4SaveOutput, unit fileio.UnitData) string {
+	row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+	return fmt.Sprintf("(row %v, col %v)", row, col)
+}
+
+func diffUnits(oldSave *fileio.WC4SaveOutput, newSave *fileio.WC4SaveOutput) []string {
+	changes := make([]string, 0)
+	pairs, removed, added := matchUnits(oldSave, newSave)
+	for _, pair := range pairs {
+		oldUnit := oldSave.Units[pair[0]]
+		newUnit := newSave.Units[pair[1]]
+		if oldUnit == newUnit {
+			continue
+		}
+
+		prefix := fmt.Sprintf("Unit %v", pair[0])
+		if pair[0] != pair[1] {
+			prefix = fmt.Sprintf("Unit %v (now %v)", pair[0], pair[1])
+		}
+		if oldUnit.CoordinateCode != newUnit.CoordinateCode {
+			changes = append(changes, fmt.Sprintf("%v moved from %v to %v", prefix, describeUnitTile(oldSave, oldUnit), describeUnitTile(newSave, newUnit)))
+		}
+		if newUnit.CurrentHealth < oldUnit.CurrentHealth {
+			changes = append(changes, fmt.Sprintf("%v damaged: health %v -> %v", prefix, oldUnit.CurrentHealth, newUnit.CurrentHealth))
+		} else if newUnit.CurrentHealth > oldUnit.CurrentHealth {
+			changes = append(changes, fmt.Sprintf("%v healed: health %v -> %v", prefix, oldUnit.CurrentHealth, newUnit.CurrentHealth))
+		}
+
+		// report the remaining fields without repeating the move and health change
+		newUnit.CoordinateCode = oldUnit.CoordinateCode
+		newUnit.CurrentHealth = oldUnit.CurrentHealth
+		changes = append(changes, diffRecords(prefix, oldUnit, newUnit)...)
+	}
+	for _, i := range removed {
+		unit := oldSave.Units[i]
+		changes = append(changes, fmt.Sprintf("Unit %v removed: type %v at %v", i, gamedata.UnitTypes.Format(int(unit.UnitType)), describeUnitTile(oldSave, unit)))
+	}
+	for _, i := range added {
+		unit := newSave.Units[i]
+		changes = append(changes, fmt.Sprintf("Unit %v added: type %v at %v", i, gamedata.UnitTypes.Format(int(unit.UnitType)), describeUnitTile(newSave, unit)))
+	}
+	return changes
+}
+
+func encodeBlock(data interface{}) ([]byte, int) {
+	buffer := &bytes.Buffer{}
+	recordSize := 0
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Slice {
+		// rows of per-tile records
+		for i := 0; i < value.Len(); i++ {
+			binary.Write(buffer, binary.LittleEndian, value.Index(i).Interface())
+		}
+		recordSize = binary.Size(reflect.Zero(value.Type().Elem().Elem()).Interface())
+	} else if value.Len() > 0 {
+		binary.Write(buffer, binary.LittleEndian, data)
+		recordSize = binary.Size(reflect.Zero(value.Type().Elem()).Interface())
+	}
+	return buffer.Bytes(), recordSize
+}
+
+// diffBlockBytes reports the byte ranges that differ in a section whose records are not decoded
+func diffBlockBytes(name string, oldData interface{}, newData interface{}) []string {
+	changes := make([]string, 0)
+	oldBytes, recordSize := encodeBlock(oldData)
+	newBytes, _ := encodeBlock(newData)
+	if len(oldBytes) != len(newBytes) {
+		changes = append(changes, fmt.Sprintf("%v size: %v -> %v bytes", name, len(oldBytes), len(newBytes)))
+	}
+	for _, byteRange := range fileio.CompareSaveData(oldBytes, newBytes) {
+		location := ""
+		if recordSize > 1 {
+			location = fmt.Sprintf(" (record %v offset %v)", byteRange.Start/recordSize, byteRange.Start%recordSize)
+		}
+		changes = append(changes, fmt.Sprintf("%v[%v:%v]%v: %v", name, byteRange.Start, byteRange.End, location, describeByteRange(oldBytes, newBytes, byteRange)))
+	}
+	return changes
+}
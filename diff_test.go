@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+func openTestSave(t *testing.T, name string) *fileio.Session {
+	t.Helper()
+	fileData, err := os.ReadFile(filepath.Join("fileio", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputFilename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(inputFilename, fileData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	session, err := fileio.Open(inputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestDiffUnitsMatchesMovedUnits(t *testing.T) {
+	oldSave := openTestSave(t, "conquest.sav").Save
+	session := openTestSave(t, "conquest.sav")
+
+	// removing unit 0 shifts unit 2 to index 1, which then moves and is healed
+	session.AllowStaleUnitReferences = true
+	if err := session.RemoveUnits([]int{0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.MoveUnit(1, 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.SetUnitHealth(1, 150); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := strings.Join(diffUnits(oldSave, session.Save), "\n")
+	for _, expected := range []string{"Unit 2 (now 1) moved from (row 2, col 5) to (row 2, col 4)", "Unit 2 (now 1) healed: health 10 -> 150", "Unit 0 removed"} {
+		if !strings.Contains(changes, expected) {
+			t.Errorf("expected %q in:\n%v", expected, changes)
+		}
+	}
+	if strings.Contains(changes, "added") {
+		t.Errorf("no unit was added:\n%v", changes)
+	}
+}
+
+func TestDiffUnitsSameCountRemovedAndAdded(t *testing.T) {
+	oldSave := openTestSave(t, "conquest.sav").Save
+	session := openTestSave(t, "conquest.sav")
+
+	// the unit count stays at 4, but unit 0 is gone and a new unit stands at (row 2, col 2)
+	session.AllowStaleUnitReferences = true
+	if err := session.RemoveUnits([]int{0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.AddUnit(fileio.NewUnit{UnitType: 9, X: 2, Y: 2, Owner: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := diffUnits(oldSave, session.Save)
+	expected := []string{
+		"Unit 0 removed: type 1 at (row 0, col 3)",
+		"Unit 3 added: type 9 at (row 2, col 2)",
+	}
+	if strings.Join(changes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(changes, "\n"))
+	}
+}
+
+func TestDiffUnitsReordered(t *testing.T) {
+	oldSave := openTestSave(t, "conquest.sav").Save
+	newSave := openTestSave(t, "conquest.sav").Save
+	newSave.Units[0], newSave.Units[2] = newSave.Units[2], newSave.Units[0]
+
+	if changes := diffUnits(oldSave, newSave); len(changes) != 0 {
+		t.Errorf("reordering units should not be reported, got:\n%v", strings.Join(changes, "\n"))
+	}
+}
+
+func TestDiffUnitsChangedOwner(t *testing.T) {
+	oldSave := openTestSave(t, "conquest.sav").Save
+	session := openTestSave(t, "conquest.sav")
+	if _, err := session.TransferTile(3, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	session.Save.Units[0].Experience = 40
+
+	changes := diffUnits(oldSave, session.Save)
+	if len(changes) != 1 || !strings.HasPrefix(changes[0], "Unit 0 Experience") {
+		t.Errorf("expected one Experience change for unit 0, got:\n%v", strings.Join(changes, "\n"))
+	}
+}
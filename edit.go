@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+// runEditCommands runs one or more write commands against a single edit session
+// and writes the save once at the end. Problems that validate would find in the
+// edited save, but not in the original, are printed as warnings. The save is backed up before it is written,
+// or with -dry-run the changed byte ranges are reported and nothing is written.
+func runEditCommands(cmds []*command) error {
+	save := cmds[0].writeSave
+	session, err := save.open()
+	if err != nil {
+		return err
+	}
+	existingIssues := make(map[fileio.ValidationIssue]bool)
+	for _, issue := range fileio.ValidateSave(session.Save) {
+		existingIssues[issue] = true
+	}
+	for _, cmd := range cmds {
+		if err := cmd.edit(session); err != nil {
+			return fmt.Errorf("%v: %w", cmd.name, err)
+		}
+	}
+	for _, issue := range fileio.ValidateSave(session.Save) {
+		if !existingIssues[issue] {
+			fmt.Println("Warning:", issue)
+		}
+	}
+
+	changedRanges, err := session.Changes()
+	if err != nil {
+		return err
+	}
+	if *save.dryRun {
+		printChangedRanges(session.Path, changedRanges)
+		return nil
+	}
+	if len(changedRanges) == 0 {
+		fmt.Println("No changes to write to", session.Path)
+		return nil
+	}
+
+	if !*save.noBackup {
+		backupFilename, err := fileio.BackupSaveFile(session.Path)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Backed up save to", backupFilename)
+	}
+	return session.Commit()
+}
+
+func printChangedRanges(inputFilename string, changedRanges []fileio.ByteRange) {
+	if len(changedRanges) == 0 {
+		fmt.Println("Dry run: no changes to", inputFilename)
+		return
+	}
+
+	changedBytes := 0
+	for _, changedRange := range changedRanges {
+		changedBytes += changedRange.End - changedRange.Start
+	}
+	fmt.Printf("Dry run: %v bytes in %v ranges would change in %v\n", changedBytes, len(changedRanges), inputFilename)
+	for _, changedRange := range changedRanges {
+		fmt.Printf("  0x%08x-0x%08x (%v bytes)\n", changedRange.Start, changedRange.End, changedRange.End-changedRange.Start)
+	}
+}
+
+// chainedFlagValue sets a flag of the same name on every command in a chain
+type chainedFlagValue []flag.Value
+
+func (v chainedFlagValue) String() string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].String()
+}
+
+func (v chainedFlagValue) Set(value string) error {
+	for _, flagValue := range v {
+		if err := flagValue.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v chainedFlagValue) IsBoolFlag() bool {
+	boolFlag, ok := v[0].(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// runCommandChain runs a comma separated list of write commands such as
+// max-money,max-city-tech,restore-allies in one open/write cycle of the save.
+// The flags are shared, so -player applies to every command that has it.
+func runCommandChain(chain string, args []string) int {
+	cmds := make([]*command, 0)
+	for _, name := range strings.Split(chain, ",") {
+		cmd, _ := findCommand([]string{name})
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "Unrecognized command in chain: %v\n\n", name)
+			printUsage(os.Stderr, "")
+			return 2
+		}
+		if cmd.edit == nil {
+			fmt.Fprintf(os.Stderr, "Only write commands can be chained, %v does not modify the save\n", name)
+			return 2
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	chainFlags := flag.NewFlagSet(chain, flag.ContinueOnError)
+	chainedValues := make(map[string]chainedFlagValue)
+	for _, cmd := range cmds {
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			if _, ok := chainedValues[f.Name]; !ok {
+				chainFlags.Var(&chainedFlagValue{}, f.Name, f.Usage)
+			}
+			chainedValues[f.Name] = append(chainedValues[f.Name], f.Value)
+		})
+	}
+	// usageFlags holds the original flags so the help shows their types and defaults
+	usageFlags := flag.NewFlagSet(chain, flag.ContinueOnError)
+	chainFlags.VisitAll(func(f *flag.Flag) {
+		*f.Value.(*chainedFlagValue) = chainedValues[f.Name]
+		f.DefValue = f.Value.String()
+		usageFlags.Var(chainedValues[f.Name][0], f.Name, f.Usage)
+	})
+	chainFlags.Usage = func() {
+		fmt.Fprintf(chainFlags.Output(), "Usage: %v %v [flags]\n\nRuns each command on the save and writes it once.\n\nFlags:\n", programName(), chain)
+		usageFlags.PrintDefaults()
+	}
+
+	if err := chainFlags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if chainFlags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %v\n\n", strings.Join(chainFlags.Args(), " "))
+		chainFlags.Usage()
+		return 2
+	}
+
+	if err := runEditCommands(cmds); err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
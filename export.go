@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+// SaveDump is the JSON representation of a save file written by dump-json.
+// Coordinates are already converted to map rows and columns. The *Name fields
+// come from the gamedata tables, are left out for IDs without a name and are
+// ignored by apply-json.
+type SaveDump struct {
+	Header     fileio.SaveHeader
+	Players    []PlayerDump
+	CityTiles  [][]uint16
+	UnitOwners [][]int // 255 means the tile has no owner
+	Cities     []CityDump
+	Units      []UnitDump
+	Generals   []GeneralDump
+	Landmines  []LandmineDump
+}
+
+type PlayerDump struct {
+	Index       int
+	CountryName string `json:",omitempty"`
+	fileio.CountryData
+}
+
+type CityDump struct {
+	Index            int
+	Row              int
+	Col              int
+	Owner            int
+	CityName         string `json:",omitempty"`
+	BuildingTypeName string `json:",omitempty"`
+	WonderName       string `json:",omitempty"`
+	fileio.CityData
+}
+
+type UnitDump struct {
+	Index        int
+	Row          int
+	Col          int
+	Owner        int
+	UnitTypeName string `json:",omitempty"`
+	fileio.UnitData
+}
+
+type GeneralDump struct {
+	UnitIndex           int
+	Row                 int
+	Col                 int
+	Owner               int
+	GeneralName         string `json:",omitempty"`
+	GeneralTitleName    string `json:",omitempty"`
+	GeneralId           uint16
+	GeneralMilitaryRank uint8
+	GeneralTitle        uint8
+	GeneralBadges       [3]byte
+	GeneralSkillLevels  [5]byte
+}
+
+type LandmineDump struct {
+	Index int
+	Row   int
+	Col   int
+	fileio.LandmineData
+}
+
+func BuildSaveDump(saveOutput *fileio.WC4SaveOutput) *SaveDump {
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	saveDump := &SaveDump{
+		Header:     saveOutput.SaveHeader,
+		Players:    make([]PlayerDump, 0),
+		CityTiles:  saveOutput.CityTiles,
+		UnitOwners: make([][]int, 0),
+		Cities:     make([]CityDump, 0),
+		Units:      make([]UnitDump, 0),
+		Generals:   make([]GeneralDump, 0),
+		Landmines:  make([]LandmineDump, 0),
+	}
+
+	for i, player := range saveOutput.PlayerData {
+		saveDump.Players = append(saveDump.Players, PlayerDump{
+			Index:       i,
+			CountryName: gamedata.Countries.Name(int(player.CountryId)),
+			CountryData: player,
+		})
+	}
+
+	for _, unitOwnerRow := range saveOutput.UnitOwnerData {
+		row := make([]int, len(unitOwnerRow))
+		for j, owner := range unitOwnerRow {
+			row[j] = int(owner)
+		}
+		saveDump.UnitOwners = append(saveDump.UnitOwners, row)
+	}
+
+	for i, city := range saveOutput.Cities {
+		row, col := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		saveDump.Cities = append(saveDump.Cities, CityDump{
+			Index:            i,
+			Row:              row,
+			Col:              col,
+			Owner:            fileio.GetTileOwner(saveOutput, row, col),
+			CityName:         gamedata.Cities.Name(int(city.CityId)),
+			BuildingTypeName: gamedata.BuildingTypes.Name(int(city.BuildingType)),
+			WonderName:       gamedata.Wonders.Name(int(city.Wonders)),
+			CityData:         city,
+		})
+	}
+
+	for i, unit := range saveOutput.Units {
+		row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		owner := fileio.GetTileOwner(saveOutput, row, col)
+		saveDump.Units = append(saveDump.Units, UnitDump{
+			Index:        i,
+			Row:          row,
+			Col:          col,
+			Owner:        owner,
+			UnitTypeName: gamedata.UnitTypes.Name(int(unit.UnitType)),
+			UnitData:     unit,
+		})
+
+		if unit.GeneralId > 0 {
+			saveDump.Generals = append(saveDump.Generals, GeneralDump{
+				UnitIndex:           i,
+				Row:                 row,
+				Col:                 col,
+				Owner:               owner,
+				GeneralName:         gamedata.Generals.Name(int(unit.GeneralId)),
+				GeneralTitleName:    gamedata.GeneralTitles.Name(int(unit.GeneralTitle)),
+				GeneralId:           unit.GeneralId,
+				GeneralMilitaryRank: unit.GeneralMilitaryRank,
+				GeneralTitle:        unit.GeneralTitle,
+				GeneralBadges:       unit.GeneralBadges,
+				GeneralSkillLevels:  unit.GeneralSkillLevels,
+			})
+		}
+	}
+
+	for i, landmine := range saveOutput.Landmines {
+		row, col := fileio.ConvertCoordinates(int(landmine.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		saveDump.Landmines = append(saveDump.Landmines, LandmineDump{
+			Index:        i,
+			Row:          row,
+			Col:          col,
+			LandmineData: landmine,
+		})
+	}
+
+	return saveDump
+}
+
+func WriteSaveDumpJson(w io.Writer, saveOutput *fileio.WC4SaveOutput) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(BuildSaveDump(saveOutput))
+}
@@ -0,0 +1,160 @@
+package fileio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const backupTimestampFormat = "20060102-150405"
+
+// ByteRange is a half-open range [Start, End) of offsets into the uncompressed save data
+type ByteRange struct {
+	Start int
+	End   int
+}
+
+func copyFile(sourceFilename string, destinationFilename string) error {
+	data, err := os.ReadFile(sourceFilename)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(sourceFilename)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(destinationFilename, data); err != nil {
+		return err
+	}
+	return os.Chmod(destinationFilename, fileInfo.Mode().Perm())
+}
+
+func backupPrefix(inputFilename string) string {
+	return inputFilename + ".bak-"
+}
+
+// BackupSaveFile copies the save to <save>.bak-<timestamp> in the same directory and returns the backup filename.
+// A second backup in the same second gets a counter, e.g. <save>.bak-<timestamp>-2.
+func BackupSaveFile(inputFilename string) (string, error) {
+	timestamp := time.Now().Format(backupTimestampFormat)
+	backupFilename := backupPrefix(inputFilename) + timestamp
+	for i := 2; ; i++ {
+		_, err := os.Stat(backupFilename)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to back up save: %w", err)
+		}
+		backupFilename = fmt.Sprintf("%v%v-%v", backupPrefix(inputFilename), timestamp, i)
+	}
+
+	if err := copyFile(inputFilename, backupFilename); err != nil {
+		return "", fmt.Errorf("failed to back up save: %w", err)
+	}
+	return backupFilename, nil
+}
+
+// parseBackupSuffix returns the time and counter in the name of a backup, or ok false
+// if the name wasn't made by BackupSaveFile. The first backup in a second has counter 1.
+func parseBackupSuffix(suffix string) (time.Time, int, bool) {
+	if len(suffix) < len(backupTimestampFormat) {
+		return time.Time{}, 0, false
+	}
+	backupTime, err := time.Parse(backupTimestampFormat, suffix[:len(backupTimestampFormat)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	counterText := suffix[len(backupTimestampFormat):]
+	if counterText == "" {
+		return backupTime, 1, true
+	}
+	counter, err := strconv.Atoi(strings.TrimPrefix(counterText, "-"))
+	if err != nil || !strings.HasPrefix(counterText, "-") || counter < 2 {
+		return time.Time{}, 0, false
+	}
+	return backupTime, counter, true
+}
+
+// FindBackups lists the backups made by BackupSaveFile, newest first
+func FindBackups(inputFilename string) ([]string, error) {
+	matches, err := filepath.Glob(backupPrefix(inputFilename) + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		filename string
+		time     time.Time
+		counter  int
+	}
+	backups := make([]backup, 0, len(matches))
+	for _, match := range matches {
+		backupTime, counter, ok := parseBackupSuffix(strings.TrimPrefix(match, backupPrefix(inputFilename)))
+		if !ok {
+			continue
+		}
+		backups = append(backups, backup{filename: match, time: backupTime, counter: counter})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].counter > backups[j].counter
+	})
+
+	backupFilenames := make([]string, len(backups))
+	for i, backup := range backups {
+		backupFilenames[i] = backup.filename
+	}
+	return backupFilenames, nil
+}
+
+// RestoreBackup replaces the save with the contents of a backup
+func RestoreBackup(inputFilename string, backupFilename string) error {
+	if err := copyFile(backupFilename, inputFilename); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return nil
+}
+
+// CompareSaveData returns the ranges that differ between two uncompressed saves
+func CompareSaveData(oldData []byte, newData []byte) []ByteRange {
+	changedRanges := make([]ByteRange, 0)
+	commonLength := len(oldData)
+	if len(newData) < commonLength {
+		commonLength = len(newData)
+	}
+
+	rangeStart := -1
+	for i := 0; i < commonLength; i++ {
+		if oldData[i] != newData[i] {
+			if rangeStart < 0 {
+				rangeStart = i
+			}
+		} else if rangeStart >= 0 {
+			changedRanges = append(changedRanges, ByteRange{Start: rangeStart, End: i})
+			rangeStart = -1
+		}
+	}
+	if rangeStart >= 0 {
+		changedRanges = append(changedRanges, ByteRange{Start: rangeStart, End: commonLength})
+	}
+
+	if len(oldData) != len(newData) {
+		longerLength := len(oldData)
+		if len(newData) > longerLength {
+			longerLength = len(newData)
+		}
+		if len(changedRanges) > 0 && changedRanges[len(changedRanges)-1].End == commonLength {
+			changedRanges[len(changedRanges)-1].End = longerLength
+		} else {
+			changedRanges = append(changedRanges, ByteRange{Start: commonLength, End: longerLength})
+		}
+	}
+	return changedRanges
+}
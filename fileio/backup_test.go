@@ -0,0 +1,77 @@
+package fileio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindBackupsNewestFirst(t *testing.T) {
+	inputFilename := filepath.Join(t.TempDir(), "game.sav")
+	backupNames := []string{
+		"20261017-235959",
+		"20261018-101500",
+		"20261018-101500-2",
+		"20261018-101500-10",
+		"20261018-091500-3",
+		"notes.txt",
+		"20261018-101500-x",
+	}
+	for _, name := range append([]string{""}, backupNames...) {
+		filename := inputFilename
+		if name != "" {
+			filename = backupPrefix(inputFilename) + name
+		}
+		if err := os.WriteFile(filename, []byte("save"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := FindBackups(inputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		backupPrefix(inputFilename) + "20261018-101500-10",
+		backupPrefix(inputFilename) + "20261018-101500-2",
+		backupPrefix(inputFilename) + "20261018-101500",
+		backupPrefix(inputFilename) + "20261018-091500-3",
+		backupPrefix(inputFilename) + "20261017-235959",
+	}
+	if !reflect.DeepEqual(backups, expected) {
+		t.Errorf("expected %v, got %v", expected, backups)
+	}
+}
+
+func TestBackupSaveFileCounter(t *testing.T) {
+	inputFilename := filepath.Join(t.TempDir(), "game.sav")
+	if err := os.WriteFile(inputFilename, []byte("save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := BackupSaveFile(inputFilename); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := FindBackups(inputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 backups, got %v", backups)
+	}
+}
+
+func TestBackupSaveFileStatError(t *testing.T) {
+	// the backup name is longer than a file name may be, so Stat fails with an
+	// error other than "not exist" and the backup must fail instead of looping
+	inputFilename := filepath.Join(t.TempDir(), strings.Repeat("a", 240))
+	if err := os.WriteFile(inputFilename, []byte("save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BackupSaveFile(inputFilename); err == nil {
+		t.Fatal("expected an error for a backup name that is too long")
+	}
+}
@@ -0,0 +1,86 @@
+package fileio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+// FindCityById returns the index of the city with a CityId or -1
+func (s *Session) FindCityById(cityId int) int {
+	for i, city := range s.Save.Cities {
+		if int(city.CityId) == cityId {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindCityAt returns the index of the city whose center is on a tile or -1
+func (s *Session) FindCityAt(x int, y int) int {
+	return s.findCityAt(y, x)
+}
+
+// FindCitiesOwnedBy returns the indices of the cities whose center tile belongs to a player
+func (s *Session) FindCitiesOwnedBy(owner int) []int {
+	gameMode := int(s.Save.SaveHeader.GameMode)
+	cityIndices := make([]int, 0)
+	for i, city := range s.Save.Cities {
+		row, col := ConvertCoordinates(int(city.CoordinateCode), s.Save.UnitOwnerData, gameMode)
+		if GetTileOwner(s.Save, row, col) == owner {
+			cityIndices = append(cityIndices, i)
+		}
+	}
+	return cityIndices
+}
+
+// SetCityBuilding sets the building type of a city. Building types, wonders and anti-air
+// weapons are checked against the name tables when names for them are loaded.
+// The game's own ranges are not known, so without names only the byte range is checked.
+func (s *Session) SetCityBuilding(city int, buildingType int) error {
+	if err := s.checkCity(city); err != nil {
+		return err
+	}
+	if err := gamedata.BuildingTypes.Check(buildingType, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Cities[city].BuildingType = uint8(buildingType)
+	return nil
+}
+
+// SetCityWonder sets the wonder built in a city
+func (s *Session) SetCityWonder(city int, wonder int) error {
+	if err := s.checkCity(city); err != nil {
+		return err
+	}
+	if err := gamedata.Wonders.Check(wonder, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Cities[city].Wonders = uint8(wonder)
+	return nil
+}
+
+// SetCityAntiAirWeapon sets the anti-air weapon type of a city
+func (s *Session) SetCityAntiAirWeapon(city int, weaponType int) error {
+	if err := s.checkCity(city); err != nil {
+		return err
+	}
+	if err := gamedata.AntiAirWeapons.Check(weaponType, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Cities[city].AntiAirWeaponType = uint8(weaponType)
+	return nil
+}
+
+// SetCityAntiAirRange sets the range of the anti-air weapon of a city
+func (s *Session) SetCityAntiAirRange(city int, antiAirRange int) error {
+	if err := s.checkCity(city); err != nil {
+		return err
+	}
+	if antiAirRange < 0 || antiAirRange > math.MaxUint8 {
+		return fmt.Errorf("anti-air range must be between 0 and %v, got %v", math.MaxUint8, antiAirRange)
+	}
+	s.Save.Cities[city].AntiAirRange = uint8(antiAirRange)
+	return nil
+}
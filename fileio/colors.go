@@ -0,0 +1,56 @@
+package fileio
+
+import (
+	"image/color"
+)
+
+var (
+	EmptyTileColor    = color.RGBA{48, 48, 48, 255}
+	UnknownOwnerColor = color.RGBA{128, 128, 128, 255}
+)
+
+// PlayerColor returns the player's primary color, or a neutral gray if the owner is not a player
+func PlayerColor(saveOutput *WC4SaveOutput, owner int) color.RGBA {
+	if owner < 0 || owner >= len(saveOutput.PlayerData) {
+		return UnknownOwnerColor
+	}
+	primaryColor := saveOutput.PlayerData[owner].PrimaryColor
+	return color.RGBA{primaryColor[0], primaryColor[1], primaryColor[2], 255}
+}
+
+// BlendColor mixes amount of foreground with 1-amount of background
+func BlendColor(foreground color.RGBA, background color.RGBA, amount float64) color.RGBA {
+	blend := func(a uint8, b uint8) uint8 {
+		return uint8(float64(a)*amount + float64(b)*(1-amount))
+	}
+	return color.RGBA{blend(foreground.R, background.R), blend(foreground.G, background.G), blend(foreground.B, background.B), 255}
+}
+
+// TileColors returns the color of every tile of the ownership map, indexed by row and column.
+// Tiles holding a unit or city owned by a player use the player's full color, tiles inside
+// a city's territory use a faded color of the city owner.
+func TileColors(saveOutput *WC4SaveOutput) [][]color.RGBA {
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	cityOwners := make(map[uint16]int)
+	for _, city := range saveOutput.Cities {
+		row, col := ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		cityOwners[city.CoordinateCode] = GetTileOwner(saveOutput, row, col)
+	}
+
+	tileColors := make([][]color.RGBA, 0, len(saveOutput.UnitOwnerData))
+	for i, unitOwnerRow := range saveOutput.UnitOwnerData {
+		colorRow := make([]color.RGBA, len(unitOwnerRow))
+		for j, owner := range unitOwnerRow {
+			colorRow[j] = EmptyTileColor
+			if owner != NoTileOwner {
+				colorRow[j] = PlayerColor(saveOutput, int(owner))
+			} else if i < len(saveOutput.CityTiles) && j < len(saveOutput.CityTiles[i]) {
+				if cityOwner, ok := cityOwners[saveOutput.CityTiles[i][j]]; ok && cityOwner != NoTileOwner {
+					colorRow[j] = BlendColor(PlayerColor(saveOutput, cityOwner), EmptyTileColor, 0.4)
+				}
+			}
+		}
+		tileColors = append(tileColors, colorRow)
+	}
+	return tileColors
+}
@@ -0,0 +1,98 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	lz4FrameMagic       = 0x184D2204
+	lz4LegacyFrameMagic = 0x184C2102
+)
+
+// SaveCompression describes the LZ4 frame of a compressed save, so it can be written back with the same framing
+type SaveCompression struct {
+	Legacy          bool
+	BlockSize       lz4.BlockSize
+	BlockChecksum   bool
+	ContentChecksum bool
+	ContentSize     bool
+}
+
+// detectCompression returns the LZ4 framing of fileData, or nil if the save is not compressed
+func detectCompression(fileData []byte) *SaveCompression {
+	if len(fileData) < 4 {
+		return nil
+	}
+
+	switch binary.LittleEndian.Uint32(fileData) {
+	case lz4LegacyFrameMagic:
+		return &SaveCompression{Legacy: true}
+	case lz4FrameMagic:
+		compression := &SaveCompression{BlockSize: lz4.Block4Mb}
+		if len(fileData) >= 6 {
+			// frame descriptor: FLG byte followed by BD byte
+			flags := fileData[4]
+			compression.BlockChecksum = flags&0x10 != 0
+			compression.ContentSize = flags&0x08 != 0
+			compression.ContentChecksum = flags&0x04 != 0
+			switch (fileData[5] >> 4) & 0x7 {
+			case 4:
+				compression.BlockSize = lz4.Block64Kb
+			case 5:
+				compression.BlockSize = lz4.Block256Kb
+			case 6:
+				compression.BlockSize = lz4.Block1Mb
+			}
+		}
+		return compression
+	}
+	return nil
+}
+
+// decompressSave returns the uncompressed save data and the framing it was stored with
+func decompressSave(fileData []byte) ([]byte, *SaveCompression, error) {
+	compression := detectCompression(fileData)
+	if compression == nil {
+		return fileData, nil, nil
+	}
+
+	saveData, err := io.ReadAll(lz4.NewReader(bytes.NewReader(fileData)))
+	if err != nil {
+		return nil, nil, &SectionError{Section: "lz4 frame", Offset: 0, Err: err}
+	}
+	return saveData, compression, nil
+}
+
+// compressSave writes saveData to w using the given framing, or unchanged if compression is nil
+func compressSave(w io.Writer, saveData []byte, compression *SaveCompression) error {
+	if compression == nil {
+		_, err := w.Write(saveData)
+		return err
+	}
+
+	options := []lz4.Option{
+		lz4.BlockSizeOption(compression.BlockSize),
+		lz4.BlockChecksumOption(compression.BlockChecksum),
+		lz4.ChecksumOption(compression.ContentChecksum),
+	}
+	if compression.ContentSize {
+		options = append(options, lz4.SizeOption(uint64(len(saveData))))
+	}
+	if compression.Legacy {
+		options = []lz4.Option{lz4.LegacyOption(true)}
+	}
+
+	lz4Writer := lz4.NewWriter(w)
+	if err := lz4Writer.Apply(options...); err != nil {
+		return fmt.Errorf("failed to configure lz4 writer: %w", err)
+	}
+	if _, err := lz4Writer.Write(saveData); err != nil {
+		return fmt.Errorf("failed to compress save: %w", err)
+	}
+	return lz4Writer.Close()
+}
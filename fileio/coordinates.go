@@ -0,0 +1,31 @@
+package fileio
+
+// ConvertCoordinates returns the row and column of a coordinate code, or -1, -1 if the map is empty
+func ConvertCoordinates(coordinateCode int, unitOwnerData [][]byte, gameMode int) (int, int) {
+	if len(unitOwnerData) == 0 || len(unitOwnerData[0]) == 0 {
+		return -1, -1
+	}
+	row := int(coordinateCode) / len(unitOwnerData[0])
+	if gameMode == 2 { // subtract 2 from row if conquest
+		row -= 2
+	}
+
+	col := int(coordinateCode) % len(unitOwnerData[0])
+	return row, col
+}
+
+// GetTileOwner returns the owner byte at the given tile or -1 if the tile is outside the map
+func GetTileOwner(saveOutput *WC4SaveOutput, row int, col int) int {
+	if row < 0 || row >= len(saveOutput.UnitOwnerData) || col < 0 || col >= len(saveOutput.UnitOwnerData[row]) {
+		return -1
+	}
+	return int(saveOutput.UnitOwnerData[row][col])
+}
+
+// BuildCoordinateCode is the inverse of ConvertCoordinates
+func BuildCoordinateCode(row int, col int, unitOwnerData [][]byte, gameMode int) int {
+	if gameMode == 2 { // rows are shifted by 2 in conquest
+		row += 2
+	}
+	return row*len(unitOwnerData[0]) + col
+}
@@ -0,0 +1,190 @@
+package fileio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RecordField is one field of a fixed-size record, or one element of an array field,
+// with its position relative to the start of the record.
+type RecordField struct {
+	Name   string
+	Offset int
+	Size   int
+	Type   reflect.Type
+}
+
+// GetRecordFields lists the fields of a record struct in file order.
+// Offsets follow the same packed little-endian layout that binary.Read uses.
+func GetRecordFields(record interface{}) []RecordField {
+	recordType := reflect.TypeOf(record)
+	fields := make([]RecordField, 0, recordType.NumField())
+	offset := 0
+	for i := 0; i < recordType.NumField(); i++ {
+		structField := recordType.Field(i)
+		size := binary.Size(reflect.Zero(structField.Type).Interface())
+		fields = append(fields, RecordField{
+			Name:   structField.Name,
+			Offset: offset,
+			Size:   size,
+			Type:   structField.Type,
+		})
+		offset += size
+	}
+	return fields
+}
+
+// ExpandRecordFields lists the fields of a record like GetRecordFields,
+// but splits array fields into one field per element. Elements of nested
+// arrays are split as well, e.g. UnknownColor[0][1].
+func ExpandRecordFields(record interface{}) []RecordField {
+	fields := make([]RecordField, 0)
+	for _, field := range GetRecordFields(record) {
+		fields = appendExpandedField(fields, field)
+	}
+	return fields
+}
+
+func appendExpandedField(fields []RecordField, field RecordField) []RecordField {
+	if field.Type.Kind() != reflect.Array {
+		return append(fields, field)
+	}
+	elementSize := field.Size / field.Type.Len()
+	for i := 0; i < field.Type.Len(); i++ {
+		fields = appendExpandedField(fields, RecordField{
+			Name:   fmt.Sprintf("%v[%v]", field.Name, i),
+			Offset: field.Offset + i*elementSize,
+			Size:   elementSize,
+			Type:   field.Type.Elem(),
+		})
+	}
+	return fields
+}
+
+// parseFieldName splits a field name like "UnknownColor[0][1]" into the
+// struct field name and the array indices that follow it
+func parseFieldName(fieldName string) (string, []int, error) {
+	bracket := strings.Index(fieldName, "[")
+	if bracket < 0 {
+		return fieldName, nil, nil
+	}
+	baseName := fieldName[:bracket]
+	indices := make([]int, 0)
+	rest := fieldName[bracket:]
+	for rest != "" {
+		closing := strings.Index(rest, "]")
+		if rest[0] != '[' || closing < 0 {
+			return "", nil, fmt.Errorf("invalid field name %v", fieldName)
+		}
+		index, err := strconv.Atoi(rest[1:closing])
+		if err != nil || index < 0 {
+			return "", nil, fmt.Errorf("invalid array index in field name %v", fieldName)
+		}
+		indices = append(indices, index)
+		rest = rest[closing+1:]
+	}
+	return baseName, indices, nil
+}
+
+// LookupRecordField finds a field by name. Array elements are selected with
+// an index, e.g. "TechLevels[2]" or "Currency[0]", and elements of nested
+// arrays with one index per level, e.g. "UnknownColor[0][1]".
+func LookupRecordField(record interface{}, fieldName string) (RecordField, error) {
+	baseName, indices, err := parseFieldName(fieldName)
+	if err != nil {
+		return RecordField{}, err
+	}
+
+	for _, field := range GetRecordFields(record) {
+		if field.Name != baseName {
+			continue
+		}
+
+		selectedName := field.Name
+		for _, index := range indices {
+			if field.Type.Kind() != reflect.Array {
+				return RecordField{}, fmt.Errorf("%v is not an array", selectedName)
+			}
+			if index >= field.Type.Len() {
+				return RecordField{}, fmt.Errorf("%v only has %v elements", selectedName, field.Type.Len())
+			}
+			elementSize := field.Size / field.Type.Len()
+			selectedName = fmt.Sprintf("%v[%v]", selectedName, index)
+			field = RecordField{
+				Name:   selectedName,
+				Offset: field.Offset + index*elementSize,
+				Size:   elementSize,
+				Type:   field.Type.Elem(),
+			}
+		}
+		if field.Type.Kind() == reflect.Array {
+			return RecordField{}, fmt.Errorf("%v is an array of %v values, select one element like %v[0]", field.Name, field.Type.Len(), field.Name)
+		}
+		return field, nil
+	}
+	return RecordField{}, fmt.Errorf("%v has no field %v", reflect.TypeOf(record).Name(), baseName)
+}
+
+func (field RecordField) getFieldValue(recordValue reflect.Value) reflect.Value {
+	baseName, indices, _ := parseFieldName(field.Name)
+	fieldValue := recordValue.FieldByName(baseName)
+	for _, index := range indices {
+		fieldValue = fieldValue.Index(index)
+	}
+	return fieldValue
+}
+
+// GetValue reads the field from a record of the type it was looked up from
+func (field RecordField) GetValue(record interface{}) reflect.Value {
+	return field.getFieldValue(reflect.Indirect(reflect.ValueOf(record)))
+}
+
+// SetValue range checks value and stores it in the record that recordPtr points to
+func (field RecordField) SetValue(recordPtr interface{}, value int64) error {
+	if err := field.CheckValue(value); err != nil {
+		return err
+	}
+	recordValue := reflect.ValueOf(recordPtr)
+	if recordValue.Kind() != reflect.Ptr {
+		return fmt.Errorf("cannot set %v on a record that is not a pointer", field.Name)
+	}
+
+	fieldValue := field.getFieldValue(recordValue.Elem())
+	switch fieldValue.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		fieldValue.SetInt(value)
+	default:
+		fieldValue.SetUint(uint64(value))
+	}
+	return nil
+}
+
+// CheckValue returns an error if value does not fit in the field's type
+func (field RecordField) CheckValue(value int64) error {
+	var minValue, maxValue int64
+	switch field.Type.Kind() {
+	case reflect.Uint8:
+		minValue, maxValue = 0, math.MaxUint8
+	case reflect.Uint16:
+		minValue, maxValue = 0, math.MaxUint16
+	case reflect.Uint32:
+		minValue, maxValue = 0, math.MaxUint32
+	case reflect.Int8:
+		minValue, maxValue = math.MinInt8, math.MaxInt8
+	case reflect.Int16:
+		minValue, maxValue = math.MinInt16, math.MaxInt16
+	case reflect.Int32:
+		minValue, maxValue = math.MinInt32, math.MaxInt32
+	default:
+		return fmt.Errorf("%v has type %v and cannot be set to an integer", field.Name, field.Type)
+	}
+
+	if value < minValue || value > maxValue {
+		return fmt.Errorf("%v is a %v and must be between %v and %v, got %v", field.Name, field.Type, minValue, maxValue, value)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package fileio
+
+import (
+	"testing"
+)
+
+func TestLookupRecordField(t *testing.T) {
+	testCases := []struct {
+		record    interface{}
+		fieldName string
+		offset    int
+		size      int
+	}{
+		{UnitData{}, "Experience", 8, 2},
+		{CityData{}, "TechLevels[0]", 24, 1},
+		{CityData{}, "TechLevels[5]", 29, 1},
+		{CountryData{}, "Currency[2]", 16, 4},
+		{CountryData{}, "UnknownColor[0][1]", 33, 1},
+		{CountryData{}, "UnknownColor[1][3]", 39, 1},
+	}
+	for _, testCase := range testCases {
+		field, err := LookupRecordField(testCase.record, testCase.fieldName)
+		if err != nil {
+			t.Errorf("%v: %v", testCase.fieldName, err)
+			continue
+		}
+		if field.Offset != testCase.offset || field.Size != testCase.size {
+			t.Errorf("%v: expected offset %v and size %v, got offset %v and size %v",
+				testCase.fieldName, testCase.offset, testCase.size, field.Offset, field.Size)
+		}
+	}
+}
+
+func TestLookupRecordFieldInvalid(t *testing.T) {
+	testCases := []struct {
+		record    interface{}
+		fieldName string
+	}{
+		{UnitData{}, "Experience[-1]"},
+		{UnitData{}, "Experience[0]"},
+		{UnitData{}, "Missing"},
+		{CityData{}, "TechLevels"},
+		{CityData{}, "TechLevels[6]"},
+		{CityData{}, "TechLevels[-1]"},
+		{CityData{}, "TechLevels[1"},
+		{CityData{}, "TechLevels[1]x"},
+		{CountryData{}, "UnknownColor[0]"},
+		{CountryData{}, "UnknownColor[2][0]"},
+		{CountryData{}, "UnknownColor[0][4]"},
+		{CountryData{}, "UnknownColor[0][1][0]"},
+	}
+	for _, testCase := range testCases {
+		if field, err := LookupRecordField(testCase.record, testCase.fieldName); err == nil {
+			t.Errorf("%v: expected an error, got field %+v", testCase.fieldName, field)
+		}
+	}
+}
+
+func TestExpandedFieldsCanBeSet(t *testing.T) {
+	for _, record := range []interface{}{CountryData{}, CityData{}, UnitData{}, LandmineData{}} {
+		for _, expandedField := range ExpandRecordFields(record) {
+			field, err := LookupRecordField(record, expandedField.Name)
+			if err != nil {
+				t.Errorf("%v is listed but can't be looked up: %v", expandedField.Name, err)
+				continue
+			}
+			if field != expandedField {
+				t.Errorf("%v: listed as %+v, looked up as %+v", expandedField.Name, expandedField, field)
+			}
+		}
+	}
+
+	country := CountryData{}
+	field, err := LookupRecordField(country, "UnknownColor[1][2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := field.SetValue(&country, 200); err != nil {
+		t.Fatal(err)
+	}
+	if country.UnknownColor[1][2] != 200 {
+		t.Errorf("expected UnknownColor[1][2] to be 200, got %v", country.UnknownColor[1][2])
+	}
+}
@@ -0,0 +1,174 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+var updateFixtures = flag.Bool("update", false, "rewrite the saves in testdata from buildFixture")
+
+const (
+	fixtureWidth  = 6
+	fixtureHeight = 5
+)
+
+// buildFixture builds a small synthetic save byte by byte from the record layouts in
+// reader.go, without going through WriteSaveFile. No real game saves are checked in,
+// so the fixtures only cover the layout as it is decoded, see testdata/README.md.
+//
+// The map is 6x5 with 3 players, city 0 at row 1 col 1 owned by player 0, city 1 at
+// row 3 col 4 owned by player 1, four units and one landmine. Campaign saves get the
+// per-tile campaign block, and shifted saves the padding after the tile grids.
+func buildFixture(gameMode uint32, shifted bool) []byte {
+	b := &bytes.Buffer{}
+	write := func(data interface{}) {
+		if err := binary.Write(b, binary.LittleEndian, data); err != nil {
+			panic(err)
+		}
+	}
+	// filled writes count records of the same size as record, every byte set to value
+	filled := func(record interface{}, count int, value byte) {
+		for i := 0; i < count; i++ {
+			b.Write(bytes.Repeat([]byte{value}, binary.Size(record)))
+			value += 1
+		}
+	}
+
+	header := SaveHeader{
+		GameMode:           gameMode,
+		TurnNumber:         7,
+		MapWidth:           fixtureWidth,
+		MapHeight:          fixtureHeight,
+		CountryCount:       3,
+		CityCount:          2,
+		UnitCount:          4,
+		LandmineCount:      1,
+		UnknownCount1:      1,
+		UnknownCount2:      1,
+		UnknownCount3:      1,
+		UnknownCount5:      2,
+		UnknownCount6:      1,
+		ImportantCityCount: 2,
+		UnknownCount9:      1,
+		UnknownInt10:       fixtureWidth * fixtureHeight,
+	}
+	copy(header.Magic[:], "WC4S")
+	if shifted {
+		header.UnknownInt10 = 99
+	}
+	write(header)
+
+	primaryColors := [][4]byte{{255, 0, 0, 255}, {0, 0, 255, 255}, {0, 200, 0, 255}}
+	for i, primaryColor := range primaryColors {
+		country := CountryData{
+			TurnOrder:    uint32(i),
+			CountryId:    uint32(10 + i),
+			Currency:     [CurrencyCount]uint32{100, 50, 20},
+			TeamId:       uint32(i % 2),
+			PrimaryColor: primaryColor,
+		}
+		country.UnknownArr5[3] = byte(i + 1)
+		write(country)
+	}
+
+	rowOffset := 0
+	if gameMode == 2 {
+		rowOffset = 2
+	}
+	coordinateCode := func(row int, col int) uint16 {
+		return uint16((row+rowOffset)*fixtureWidth + col)
+	}
+
+	if gameMode != 2 {
+		for i := 0; i < fixtureWidth*fixtureHeight; i++ {
+			write(CampaignTileData{UnknownArr1: [16]byte{byte(i)}})
+		}
+	}
+	city0, city1 := coordinateCode(1, 1), coordinateCode(3, 4)
+	for row := 0; row < fixtureHeight; row++ {
+		for col := 0; col < fixtureWidth; col++ {
+			cityTile := uint16(0)
+			if row <= 2 && col <= 2 {
+				cityTile = city0
+			} else if row >= 2 && col >= 3 {
+				cityTile = city1
+			}
+			write(cityTile)
+		}
+	}
+	if gameMode != 2 && shifted {
+		b.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	}
+
+	owners := bytes.Repeat([]byte{NoTileOwner}, fixtureWidth*fixtureHeight)
+	owners[1*fixtureWidth+1] = 0 // city 0
+	owners[3*fixtureWidth+4] = 1 // city 1
+	owners[0*fixtureWidth+3] = 0 // unit 0
+	owners[4*fixtureWidth+0] = 1 // unit 1
+	owners[2*fixtureWidth+5] = 2 // unit 2
+	owners[4*fixtureWidth+5] = 1 // unit 3
+	b.Write(owners)
+	if gameMode != 2 && shifted {
+		b.Write([]byte{9, 9, 9, 9})
+	}
+
+	write(CityData{CoordinateCode: city0, CityId: 3, BuildingType: 1})
+	write(CityData{CoordinateCode: city1, CityId: 8, BuildingType: 2, TechLevels: [CityTechCount]byte{1, 1, 0, 2, 0, 1}})
+	write(UnitData{CoordinateCode: coordinateCode(0, 3), UnitType: 1, Level: 1, CurrentHealth: 50, MaxHealth: 100})
+	write(UnitData{CoordinateCode: coordinateCode(4, 0), UnitType: 5, Level: 2, CurrentHealth: 80, MaxHealth: 120,
+		GeneralId: 12, GeneralMilitaryRank: 2, GeneralSkillLevels: [5]byte{1, 2, 3, 1, 0}})
+	write(UnitData{CoordinateCode: coordinateCode(2, 5), UnitType: 9, CurrentHealth: 10, MaxHealth: 200})
+	write(UnitData{CoordinateCode: coordinateCode(4, 5), UnitType: 39, CurrentHealth: 300, MaxHealth: 300})
+	write(LandmineData{CoordinateCode: coordinateCode(0, 0), Owner: 1, Health: 5})
+
+	filled(UnknownData2{}, 1, 2)
+	filled(UnknownData3{}, 1, 3)
+	filled(UnknownData4{}, 1, 4)
+	filled(UnknownData5{}, 2, 5)
+	filled(UnknownData5{}, 1, 7) // unknown block 6
+	filled(ImportantCityData{}, 2, 8)
+	filled(UnknownData7{}, 1, 10)
+	return b.Bytes()
+}
+
+// compressFixture frames a save the way the LZ4 saves seen so far are framed
+func compressFixture(saveData []byte) []byte {
+	b := &bytes.Buffer{}
+	w := lz4.NewWriter(b)
+	if err := w.Apply(lz4.BlockSizeOption(lz4.Block64Kb), lz4.ChecksumOption(true)); err != nil {
+		panic(err)
+	}
+	if _, err := w.Write(saveData); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return b.Bytes()
+}
+
+func TestFixturesMatchGenerator(t *testing.T) {
+	conquestSave := buildFixture(2, false)
+	fixtures := map[string][]byte{
+		"conquest.sav":     conquestSave,
+		"campaign.sav":     buildFixture(1, true),
+		"conquest_lz4.sav": compressFixture(conquestSave),
+	}
+	for name, fileData := range fixtures {
+		if *updateFixtures {
+			if err := os.WriteFile(filepath.Join("testdata", name), fileData, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if !bytes.Equal(readFixture(t, name), fileData) {
+			t.Errorf("testdata/%v differs from buildFixture, run go test ./fileio -run TestFixturesMatchGenerator -update", name)
+		}
+	}
+}
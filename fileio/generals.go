@@ -0,0 +1,109 @@
+package fileio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+func (s *Session) checkGeneral(unitIndex int) error {
+	if err := s.checkUnit(unitIndex); err != nil {
+		return err
+	}
+	if s.Save.Units[unitIndex].GeneralId == 0 {
+		return fmt.Errorf("unit %v has no general", unitIndex)
+	}
+	return nil
+}
+
+// AssignGeneral puts a general in command of a unit. A general can only lead one
+// unit. Assigning general 0 removes the general and clears its rank, title, badges
+// and skills.
+func (s *Session) AssignGeneral(unitIndex int, generalId int) error {
+	if err := s.checkUnit(unitIndex); err != nil {
+		return err
+	}
+	unit := &s.Save.Units[unitIndex]
+	if generalId < 0 || generalId > math.MaxUint16 {
+		return fmt.Errorf("general must be between 0 and %v, got %v", math.MaxUint16, generalId)
+	}
+	if generalId == 0 {
+		unit.GeneralId = 0
+		unit.GeneralMilitaryRank = 0
+		unit.GeneralTitle = 0
+		unit.GeneralBadges = [gamedata.GeneralBadgeCount]byte{}
+		unit.GeneralSkillLevels = [gamedata.GeneralSkillCount]byte{}
+		return nil
+	}
+
+	if unit.UnitType == gamedata.CityUnitType {
+		return fmt.Errorf("unit %v is a city and can't have a general", unitIndex)
+	}
+	for i, otherUnit := range s.Save.Units {
+		if i != unitIndex && int(otherUnit.GeneralId) == generalId {
+			return fmt.Errorf("general %v already leads unit %v", gamedata.Generals.Format(generalId), i)
+		}
+	}
+	unit.GeneralId = uint16(generalId)
+	return nil
+}
+
+// SetGeneralRank sets the military rank of the general leading a unit. Ranks, titles
+// and badges are checked against the name tables when names for them are loaded.
+// The game's own ranges are not known, so without names only the byte range is checked.
+func (s *Session) SetGeneralRank(unitIndex int, rank int) error {
+	if err := s.checkGeneral(unitIndex); err != nil {
+		return err
+	}
+	if err := gamedata.GeneralRanks.Check(rank, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Units[unitIndex].GeneralMilitaryRank = uint8(rank)
+	return nil
+}
+
+// SetGeneralTitle sets the title of the general leading a unit
+func (s *Session) SetGeneralTitle(unitIndex int, title int) error {
+	if err := s.checkGeneral(unitIndex); err != nil {
+		return err
+	}
+	if err := gamedata.GeneralTitles.Check(title, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Units[unitIndex].GeneralTitle = uint8(title)
+	return nil
+}
+
+// SetGeneralBadge sets one of the three badge slots of the general leading a unit
+func (s *Session) SetGeneralBadge(unitIndex int, slot int, badge int) error {
+	if err := s.checkGeneral(unitIndex); err != nil {
+		return err
+	}
+	if slot < 0 || slot >= gamedata.GeneralBadgeCount {
+		return fmt.Errorf("badge slot must be between 0 and %v (a general has %v badge slots), got %v",
+			gamedata.GeneralBadgeCount-1, gamedata.GeneralBadgeCount, slot)
+	}
+	if err := gamedata.GeneralBadges.Check(badge, math.MaxUint8); err != nil {
+		return err
+	}
+	s.Save.Units[unitIndex].GeneralBadges[slot] = byte(badge)
+	return nil
+}
+
+// SetGeneralSkill sets the level of one of the five skills of the general leading a unit
+func (s *Session) SetGeneralSkill(unitIndex int, skill int, level int) error {
+	if err := s.checkGeneral(unitIndex); err != nil {
+		return err
+	}
+	if skill < 0 || skill >= gamedata.GeneralSkillCount {
+		return fmt.Errorf("skill must be between 0 and %v (a general has %v skills), got %v",
+			gamedata.GeneralSkillCount-1, gamedata.GeneralSkillCount, skill)
+	}
+	if level < 0 || level > gamedata.MaxGeneralSkillLevel {
+		return fmt.Errorf("level of skill %v must be between 0 and %v, got %v",
+			gamedata.GeneralSkills.Format(skill), gamedata.MaxGeneralSkillLevel, level)
+	}
+	s.Save.Units[unitIndex].GeneralSkillLevels[skill] = byte(level)
+	return nil
+}
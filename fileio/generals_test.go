@@ -0,0 +1,46 @@
+package fileio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetGeneralRanges(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	const unitIndex = 1 // leads general 12 in the fixture
+
+	testCases := []struct {
+		name     string
+		set      func() error
+		expected string
+	}{
+		{"rank", func() error { return session.SetGeneralRank(unitIndex, 256) }, "general rank must be between 0 and 255"},
+		{"title", func() error { return session.SetGeneralTitle(unitIndex, -1) }, "general title must be between 0 and 255"},
+		{"badge", func() error { return session.SetGeneralBadge(unitIndex, 0, 300) }, "general badge must be between 0 and 255"},
+		{"badge slot", func() error { return session.SetGeneralBadge(unitIndex, 3, 1) }, "between 0 and 2 (a general has 3 badge slots)"},
+		{"skill", func() error { return session.SetGeneralSkill(unitIndex, 5, 1) }, "between 0 and 4 (a general has 5 skills)"},
+		{"skill level", func() error { return session.SetGeneralSkill(unitIndex, 0, 6) }, "between 0 and 5"},
+		{"no general", func() error { return session.SetGeneralRank(0, 1) }, "unit 0 has no general"},
+	}
+	for _, testCase := range testCases {
+		err := testCase.set()
+		if err == nil {
+			t.Errorf("%v: expected an error", testCase.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Errorf("%v: expected an error containing %q, got %q", testCase.name, testCase.expected, err)
+		}
+	}
+
+	if err := session.SetGeneralBadge(unitIndex, 2, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.SetGeneralSkill(unitIndex, 4, 5); err != nil {
+		t.Fatal(err)
+	}
+	unit := session.Save.Units[unitIndex]
+	if unit.GeneralBadges[2] != 7 || unit.GeneralSkillLevels[4] != 5 {
+		t.Errorf("expected badge 7 in slot 2 and level 5 for skill 4, got %v and %v", unit.GeneralBadges, unit.GeneralSkillLevels)
+	}
+}
@@ -0,0 +1,75 @@
+package fileio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// SaveSection is one part of the uncompressed save, in file order
+type SaveSection struct {
+	Name    string
+	Start   int
+	End     int
+	Unknown bool            // the meaning of the section is not known
+	Records []SectionRecord // nil for sections that are plain grids or raw bytes
+}
+
+// SectionRecord is one struct of a section, decoded with the same definition the parser uses
+type SectionRecord struct {
+	Name   string
+	Start  int
+	Record interface{}
+}
+
+type layoutBuilder struct {
+	offset   int
+	sections []SaveSection
+}
+
+func (b *layoutBuilder) addRaw(name string, size int, unknown bool) {
+	if size == 0 {
+		return
+	}
+	b.sections = append(b.sections, SaveSection{Name: name, Start: b.offset, End: b.offset + size, Unknown: unknown})
+	b.offset += size
+}
+
+// addRecords adds a section holding a slice of records
+func (b *layoutBuilder) addRecords(name string, recordName string, records interface{}, unknown bool) {
+	recordList := reflect.ValueOf(records)
+	if recordList.Len() == 0 {
+		return
+	}
+	section := SaveSection{Name: name, Start: b.offset, Unknown: unknown, Records: make([]SectionRecord, 0, recordList.Len())}
+	for i := 0; i < recordList.Len(); i++ {
+		record := recordList.Index(i).Interface()
+		section.Records = append(section.Records, SectionRecord{Name: fmt.Sprintf("%v %v", recordName, i), Start: b.offset, Record: record})
+		b.offset += binary.Size(record)
+	}
+	section.End = b.offset
+	b.sections = append(b.sections, section)
+}
+
+func gridSize(grid interface{}) int {
+	size := 0
+	rows := reflect.ValueOf(grid)
+	for i := 0; i < rows.Len(); i++ {
+		size += binary.Size(rows.Index(i).Interface())
+	}
+	return size
+}
+
+// GetSaveLayout lists the sections of the uncompressed save in the order WriteSaveFile writes them.
+// The sizes come from the decoded structs, so the last section ends at the length of the save.
+func GetSaveLayout(saveOutput *WC4SaveOutput) []SaveSection {
+	b := &layoutBuilder{sections: make([]SaveSection, 0)}
+	for _, section := range saveSections {
+		if section.records != nil {
+			b.addRecords(section.name, section.recordName, section.records(saveOutput), section.unknown)
+		} else {
+			b.addRaw(section.name, section.size(saveOutput), section.unknown)
+		}
+	}
+	return b.sections
+}
@@ -0,0 +1,52 @@
+package fileio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetSaveLayoutMatchesWriter(t *testing.T) {
+	for _, fixture := range []string{"conquest.sav", "campaign.sav"} {
+		t.Run(fixture, func(t *testing.T) {
+			fileData := readFixture(t, fixture)
+			saveOutput, err := ReadSaveData(fileData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			layout := GetSaveLayout(saveOutput)
+			offset := 0
+			for _, section := range layout {
+				if section.Start != offset {
+					t.Errorf("%v starts at %v, expected %v", section.Name, section.Start, offset)
+				}
+				offset = section.End
+			}
+			if offset != len(fileData) {
+				t.Errorf("layout ends at %v, the save is %v bytes", offset, len(fileData))
+			}
+
+			// every section listed must hold exactly the bytes its descriptor writes
+			layoutSections := make(map[string]SaveSection)
+			for _, section := range layout {
+				layoutSections[section.Name] = section
+			}
+			for _, section := range saveSections {
+				buffer := &bytes.Buffer{}
+				if err := section.write(buffer, saveOutput); err != nil {
+					t.Fatalf("%v: %v", section.name, err)
+				}
+				layoutSection, ok := layoutSections[section.name]
+				if !ok {
+					if buffer.Len() != 0 {
+						t.Errorf("%v writes %v bytes but is missing from the layout", section.name, buffer.Len())
+					}
+					continue
+				}
+				if !bytes.Equal(buffer.Bytes(), fileData[layoutSection.Start:layoutSection.End]) {
+					t.Errorf("%v: written bytes differ from the save at %v-%v", section.name, layoutSection.Start, layoutSection.End)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package fileio
+
+import (
+	"errors"
+	"testing"
+)
+
+// holdings counts the tiles, cities and landmines a player owns
+func holdings(saveOutput *WC4SaveOutput, player int) OwnershipChanges {
+	counts := OwnershipChanges{}
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	for _, ownerRow := range saveOutput.UnitOwnerData {
+		for _, owner := range ownerRow {
+			if int(owner) == player {
+				counts.Tiles += 1
+			}
+		}
+	}
+	for _, city := range saveOutput.Cities {
+		row, col := ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		if GetTileOwner(saveOutput, row, col) == player {
+			counts.Cities += 1
+		}
+	}
+	for _, landmine := range saveOutput.Landmines {
+		if int(landmine.Owner) == player {
+			counts.Landmines += 1
+		}
+	}
+	return counts
+}
+
+func TestTransferPlayerRefusesImportantCities(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	before, err := session.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.TransferPlayer(1, 0); !errors.Is(err, ErrImportantCitiesNotDecoded) {
+		t.Fatalf("expected ErrImportantCitiesNotDecoded, got %v", err)
+	}
+	after, err := session.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranges := CompareSaveData(before, after); len(ranges) != 0 {
+		t.Errorf("refused transfer changed the save at %v", ranges)
+	}
+
+	// a tile without a city can still change hands
+	if _, err := session.TransferTile(5, 2, 0); err != nil {
+		t.Errorf("transfer of a unit tile: %v", err)
+	}
+}
+
+func TestTransferPlayerMovesHoldings(t *testing.T) {
+	for _, fixture := range []string{"conquest.sav", "campaign.sav"} {
+		t.Run(fixture, func(t *testing.T) {
+			session := openFixture(t, fixture)
+			session.AllowStaleImportantCities = true
+			oldPlayerBefore := holdings(session.Save, 1)
+			newPlayerBefore := holdings(session.Save, 0)
+			if oldPlayerBefore.Cities == 0 || oldPlayerBefore.Landmines == 0 {
+				t.Fatalf("fixture player 1 should own a city and a landmine, has %v", oldPlayerBefore)
+			}
+
+			changes, err := session.TransferPlayer(1, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes != oldPlayerBefore {
+				t.Errorf("expected changes %v, got %v", oldPlayerBefore, changes)
+			}
+			if oldPlayerAfter := holdings(session.Save, 1); oldPlayerAfter != (OwnershipChanges{}) {
+				t.Errorf("player 1 still owns %v", oldPlayerAfter)
+			}
+			expected := newPlayerBefore
+			expected.Add(oldPlayerBefore)
+			if newPlayerAfter := holdings(session.Save, 0); newPlayerAfter != expected {
+				t.Errorf("expected player 0 to own %v, got %v", expected, newPlayerAfter)
+			}
+			if issues := ValidateSave(session.Save); len(issues) != 0 {
+				t.Errorf("transfer left problems: %v", issues)
+			}
+		})
+	}
+}
+
+func TestTransferTileWithoutImportantCities(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	session.Save.ImportantCities = nil
+	session.Save.SaveHeader.ImportantCityCount = 0
+
+	// city 1 of player 1 is centered on column 4, row 3
+	city := session.FindCityAt(4, 3)
+	if city < 0 {
+		t.Fatal("fixture has no city at (x: 4, y: 3)")
+	}
+	changes, err := session.TransferTile(4, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Cities != 1 || changes.Tiles != 1 {
+		t.Errorf("expected 1 tile and 1 city, got %v", changes)
+	}
+	if owner := GetTileOwner(session.Save, 3, 4); owner != 2 {
+		t.Errorf("expected city owner 2, got %v", owner)
+	}
+}
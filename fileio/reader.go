@@ -1,17 +1,15 @@
 package fileio
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 )
 
-var (
-	fileOffsetMap = make(map[string]int)
-)
-
 type SaveHeader struct {
 	Magic              [4]byte
 	UnknownInt1        uint32
@@ -109,262 +107,383 @@ type LandmineData struct {
 	UnknownArr2    [4]byte
 }
 
+type CampaignTileData struct {
+	UnknownArr1 [16]byte
+}
+
+type UnknownData2 struct {
+	UnknownArr1 [16]byte
+}
+
+type UnknownData3 struct {
+	UnknownArr1 [44]byte
+}
+
+type UnknownData4 struct {
+	UnknownArr1 [80]byte
+}
+
+type UnknownData5 struct {
+	UnknownArr1 [8]byte
+}
+
+type ImportantCityData struct {
+	UnknownArr1 [4]byte
+}
+
+type UnknownData7 struct {
+	UnknownArr1 [16]byte
+}
+
 type WC4SaveOutput struct {
-	SaveHeader SaveHeader
+	SaveHeader    SaveHeader
 	PlayerData    []CountryData
 	CityTiles     [][]uint16
 	UnitOwnerData [][]byte
-	Cities []CityData
-	Units []UnitData
+	Cities        []CityData
+	Units         []UnitData
+
+	CampaignTiles   [][]CampaignTileData // campaign and frontier only, nil in conquest
+	Landmines       []LandmineData
+	UnknownData2    []UnknownData2
+	UnknownData3    []UnknownData3
+	UnknownData4    []UnknownData4
+	UnknownData5    []UnknownData5
+	UnknownData6    []UnknownData5
+	ImportantCities []ImportantCityData
+	UnknownData7    []UnknownData7
+
+	// Padding and trailing bytes are kept as raw bytes so the save can be written back unchanged
+	RawCityTilesPadding []byte
+	RawUnitOwnerPadding []byte
+	TrailingData        []byte
+
+	// LZ4 framing of the file, nil if the save is not compressed
+	Compression *SaveCompression
+}
+
+// SectionError reports a failure while decoding one section of the save file.
+type SectionError struct {
+	Section string
+	Offset  int64
+	Err     error
+}
+
+func (e *SectionError) Error() string {
+	return fmt.Sprintf("failed to load %v at offset %v: %v", e.Section, e.Offset, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
 }
 
-func DeserializeMapHeaderFromBytes(streamReader *io.SectionReader) SaveHeader {
+// checkSectionSize fails if fewer bytes are left in the save than count records of
+// recordSize bytes need. It runs before a section is allocated so a corrupt count
+// in the header returns an error instead of exhausting memory.
+func checkSectionSize(streamReader *io.SectionReader, section string, count int, recordSize int) error {
+	offset, err := streamReader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return &SectionError{Section: section, Offset: offset, Err: err}
+	}
+	remaining := streamReader.Size() - offset
+	if count < 0 || recordSize < 0 || (recordSize > 0 && int64(count) > remaining/int64(recordSize)) {
+		return &SectionError{Section: section, Offset: offset,
+			Err: fmt.Errorf("%v records of %v bytes don't fit in the %v bytes left: %w", count, recordSize, remaining, io.ErrUnexpectedEOF)}
+	}
+	return nil
+}
+
+func readSection(streamReader *io.SectionReader, section string, data interface{}) error {
+	offset, err := streamReader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return &SectionError{Section: section, Offset: offset, Err: err}
+	}
+	if err := binary.Read(streamReader, binary.LittleEndian, data); err != nil {
+		return &SectionError{Section: section, Offset: offset, Err: err}
+	}
+	return nil
+}
+
+// readGridRow reads one row of a tile grid in a single call. If the save ends inside
+// the row, the error names the row and column of the first tile that is cut off.
+func readGridRow(streamReader *io.SectionReader, section string, row int, tileSize int, data interface{}) error {
+	offset, err := streamReader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return &SectionError{Section: section, Offset: offset, Err: err}
+	}
+	rowData := make([]byte, binary.Size(data))
+	if n, err := io.ReadFull(streamReader, rowData); err != nil {
+		column := n / tileSize
+		return &SectionError{Section: fmt.Sprintf("%v (%v, %v)", section, row, column), Offset: offset + int64(column*tileSize), Err: err}
+	}
+	return binary.Read(bytes.NewReader(rowData), binary.LittleEndian, data)
+}
+
+func DeserializeMapHeaderFromBytes(streamReader *io.SectionReader, logger *log.Logger) (SaveHeader, error) {
 	mapHeaderInput := SaveHeader{}
-	if err := binary.Read(streamReader, binary.LittleEndian, &mapHeaderInput); err != nil {
-		log.Fatal("Failed to load MapHeaderInput: ", err)
+	if err := readSection(streamReader, "header", &mapHeaderInput); err != nil {
+		return SaveHeader{}, err
 	}
-	fmt.Printf("Map Header Input: %+v\n", mapHeaderInput)
-	return mapHeaderInput
+	logger.Printf("Map Header Input: %+v\n", mapHeaderInput)
+	return mapHeaderInput, nil
 }
 
-func DeserializeCountryDataFromBytes(streamReader *io.SectionReader, count int) []CountryData {
+func DeserializeCountryDataFromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]CountryData, error) {
+	if err := checkSectionSize(streamReader, "country data", count, binary.Size(CountryData{})); err != nil {
+		return nil, err
+	}
 	allPlayerData := make([]CountryData, count)
 	for i := 0; i < count; i++ {
-		updateFileOffsetMap(fileOffsetMap, streamReader, BuildPlayerStartKey(i))
-
 		countryData := CountryData{}
-		if err := binary.Read(streamReader, binary.LittleEndian, &countryData); err != nil {
-			log.Fatal("Failed to load country data: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("country data %v", i), &countryData); err != nil {
+			return nil, err
 		}
 		allPlayerData[i] = countryData
-		fmt.Printf("Player %v data: %+v\n", i, countryData)
+		logger.Printf("Player %v data: %+v\n", i, countryData)
 	}
-	return allPlayerData
+	return allPlayerData, nil
 }
 
-func DeserializeCityTileOwnershipFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int) [][]uint16 {
+func DeserializeCityTileOwnershipFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int, logger *log.Logger) ([][]uint16, error) {
+	if err := checkSectionSize(streamReader, "city tiles", mapHeight, mapWidth*binary.Size(uint16(0))); err != nil {
+		return nil, err
+	}
 	allCityTiles := make([][]uint16, 0)
 	for i := 0; i < mapHeight; i++ {
-		cityRow := make([]uint16, 0)
-		for j := 0; j < mapWidth; j++ {
-			cityCoordinates := uint16(0)
-			if err := binary.Read(streamReader, binary.LittleEndian, &cityCoordinates); err != nil {
-				log.Fatal("Failed to load city tile ownership: ", err)
-			}
-			cityRow = append(cityRow, cityCoordinates)
+		cityRow := make([]uint16, mapWidth)
+		if err := readGridRow(streamReader, "city tiles", i, binary.Size(uint16(0)), cityRow); err != nil {
+			return nil, err
 		}
 		allCityTiles = append(allCityTiles, cityRow)
-		fmt.Println("City Tile Owner Row", i, ":", cityRow)
+		logger.Println("City Tile Owner Row", i, ":", cityRow)
 	}
-	return allCityTiles
+	return allCityTiles, nil
 }
 
-func DeserializeUnknownCampaignBlockFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int) {
+func DeserializeUnknownCampaignBlockFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int, logger *log.Logger) ([][]CampaignTileData, error) {
+	if err := checkSectionSize(streamReader, "campaign block", mapHeight, mapWidth*binary.Size(CampaignTileData{})); err != nil {
+		return nil, err
+	}
+	allCampaignTiles := make([][]CampaignTileData, 0)
 	for i := 0; i < mapHeight; i++ {
-		for j := 0; j < mapWidth; j++ {
-			unknownBlock := make([]byte, 16)
-			if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-				log.Fatal("Failed to load city tile ownership: ", err)
-			}
-			fmt.Println("Unknown block:", unknownBlock)
+		campaignRow := make([]CampaignTileData, mapWidth)
+		if err := readGridRow(streamReader, "campaign block", i, binary.Size(CampaignTileData{}), campaignRow); err != nil {
+			return nil, err
 		}
+		logger.Println("Unknown block row", i, ":", campaignRow)
+		allCampaignTiles = append(allCampaignTiles, campaignRow)
 	}
+	return allCampaignTiles, nil
 }
 
-func DeserializeUnitOwnerDataFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int) [][]byte {
+func DeserializeUnitOwnerDataFromBytes(streamReader *io.SectionReader, mapWidth int, mapHeight int, logger *log.Logger) ([][]byte, error) {
+	if err := checkSectionSize(streamReader, "unit owner", mapHeight, mapWidth); err != nil {
+		return nil, err
+	}
 	unitOwnerData := make([][]byte, 0)
 
 	for i := 0; i < mapHeight; i++ {
-		unitOwnerRow := make([]byte, mapWidth*1)
-
-		for j := 0; j < mapWidth; j++ {
-			updateFileOffsetMap(fileOffsetMap, streamReader, buildSingleUnitOwnerStartKey(j, i))
-
-			if err := binary.Read(streamReader, binary.LittleEndian, &unitOwnerRow[j]); err != nil {
-				log.Fatal("Failed to load unit owner: ", err)
-			}
+		unitOwnerRow := make([]byte, mapWidth)
+		if err := readGridRow(streamReader, "unit owner", i, 1, unitOwnerRow); err != nil {
+			return nil, err
 		}
 
 		unitOwnerData = append(unitOwnerData, unitOwnerRow)
-		fmt.Println("Unit Owner Row", i, ":", unitOwnerRow)
+		logger.Println("Unit Owner Row", i, ":", unitOwnerRow)
 	}
 
-	return unitOwnerData
+	return unitOwnerData, nil
 }
 
-func DeserializeCityDataFromBytes(streamReader *io.SectionReader, count int) []CityData {
+func DeserializeCityDataFromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]CityData, error) {
+	if err := checkSectionSize(streamReader, "city data", count, binary.Size(CityData{})); err != nil {
+		return nil, err
+	}
 	allCities := make([]CityData, count)
 	for i := 0; i < count; i++ {
-		updateFileOffsetMap(fileOffsetMap, streamReader, BuildCityStartKey(i))
+		offset, err := streamReader.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
 
 		cityData := CityData{}
-		if err := binary.Read(streamReader, binary.LittleEndian, &cityData); err != nil {
-			log.Fatal("Failed to load cityData: ", err)
+		section := fmt.Sprintf("city data %v", i)
+		if err := readSection(streamReader, section, &cityData); err != nil {
+			return nil, err
 		}
 
 		allCities[i] = cityData
-		fmt.Printf("City data: %+v\n", cityData)
+		logger.Printf("City data: %+v\n", cityData)
 
 		if i > 0 && cityData.CoordinateCode == 0 {
-			log.Fatal("Invalid city data")
+			return nil, &SectionError{Section: section, Offset: offset, Err: errors.New("invalid city data")}
 		}
 	}
-	return allCities
+	return allCities, nil
 }
 
-func DeserializeUnitDataFromBytes(streamReader *io.SectionReader, count int) []UnitData {
+func DeserializeUnitDataFromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnitData, error) {
+	if err := checkSectionSize(streamReader, "unit data", count, binary.Size(UnitData{})); err != nil {
+		return nil, err
+	}
 	allUnits := make([]UnitData, count)
 	for i := 0; i < count; i++ {
-		updateFileOffsetMapForField(fileOffsetMap, streamReader, BuildUnitHealthKey(i), 12)
-
 		unitData := UnitData{}
-		if err := binary.Read(streamReader, binary.LittleEndian, &unitData); err != nil {
-			log.Fatal("Failed to load unit data: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unit data %v", i), &unitData); err != nil {
+			return nil, err
 		}
 		allUnits[i] = unitData
-		fmt.Printf("Unit data: %+v\n", unitData)
+		logger.Printf("Unit data: %+v\n", unitData)
 	}
-	return allUnits
+	return allUnits, nil
 }
 
-func DeserializeLandmineDataFromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeLandmineDataFromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]LandmineData, error) {
+	if err := checkSectionSize(streamReader, "landmine data", count, binary.Size(LandmineData{})); err != nil {
+		return nil, err
+	}
+	allLandmines := make([]LandmineData, count)
 	for i := 0; i < count; i++ {
-		landmineData := LandmineData{}
-		if err := binary.Read(streamReader, binary.LittleEndian, &landmineData); err != nil {
-			log.Fatal("Failed to load landmine data: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("landmine data %v", i), &allLandmines[i]); err != nil {
+			return nil, err
 		}
-		fmt.Printf("Landmine: %+v\n", landmineData)
+		logger.Printf("Landmine: %+v\n", allLandmines[i])
 	}
+	return allLandmines, nil
 }
 
-func DeserializeUnknownData2FromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeUnknownData2FromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnknownData2, error) {
+	if err := checkSectionSize(streamReader, "unknown block 2", count, binary.Size(UnknownData2{})); err != nil {
+		return nil, err
+	}
+	allRecords := make([]UnknownData2, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 16)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unknown block 2 record %v", i), &allRecords[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Unknown block 2:", unknownBlock)
+		logger.Println("Unknown block 2:", allRecords[i].UnknownArr1)
 	}
+	return allRecords, nil
 }
 
-func DeserializeUnknownData3FromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeUnknownData3FromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnknownData3, error) {
+	if err := checkSectionSize(streamReader, "unknown block 3", count, binary.Size(UnknownData3{})); err != nil {
+		return nil, err
+	}
+	allRecords := make([]UnknownData3, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 44)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unknown block 3 record %v", i), &allRecords[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Unknown block 3:", unknownBlock)
+		logger.Println("Unknown block 3:", allRecords[i].UnknownArr1)
 	}
+	return allRecords, nil
 }
 
-func DeserializeUnknownData4FromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeUnknownData4FromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnknownData4, error) {
+	if err := checkSectionSize(streamReader, "unknown block 4", count, binary.Size(UnknownData4{})); err != nil {
+		return nil, err
+	}
+	allRecords := make([]UnknownData4, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 80)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unknown block 4 record %v", i), &allRecords[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Unknown block 4:", unknownBlock)
+		logger.Println("Unknown block 4:", allRecords[i].UnknownArr1)
 	}
+	return allRecords, nil
 }
 
-func DeserializeUnknownData5FromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeUnknownData5FromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnknownData5, error) {
+	if err := checkSectionSize(streamReader, "unknown block 5", count, binary.Size(UnknownData5{})); err != nil {
+		return nil, err
+	}
+	allRecords := make([]UnknownData5, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 8)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unknown block 5 record %v", i), &allRecords[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Unknown block 5:", unknownBlock)
+		logger.Println("Unknown block 5:", allRecords[i].UnknownArr1)
 	}
+	return allRecords, nil
 }
 
-func DeserializeImportantCityDataFromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeImportantCityDataFromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]ImportantCityData, error) {
+	if err := checkSectionSize(streamReader, "important cities", count, binary.Size(ImportantCityData{})); err != nil {
+		return nil, err
+	}
+	allImportantCities := make([]ImportantCityData, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 4)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("important city %v", i), &allImportantCities[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Important city:", unknownBlock)
+		logger.Println("Important city:", allImportantCities[i].UnknownArr1)
 	}
+	return allImportantCities, nil
 }
 
-func DeserializeUnknownData7FromBytes(streamReader *io.SectionReader, count int) {
+func DeserializeUnknownData7FromBytes(streamReader *io.SectionReader, count int, logger *log.Logger) ([]UnknownData7, error) {
+	if err := checkSectionSize(streamReader, "unknown block 7", count, binary.Size(UnknownData7{})); err != nil {
+		return nil, err
+	}
+	allRecords := make([]UnknownData7, count)
 	for i := 0; i < count; i++ {
-		unknownBlock := make([]byte, 16)
-		if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-			log.Fatal("Failed to load unknownBlock: ", err)
+		if err := readSection(streamReader, fmt.Sprintf("unknown block 7 record %v", i), &allRecords[i]); err != nil {
+			return nil, err
 		}
-		fmt.Println("Unknown block 7:", unknownBlock)
+		logger.Println("Unknown block 7:", allRecords[i].UnknownArr1)
 	}
+	return allRecords, nil
 }
 
-func ReadSaveFile(inputFilename string) (*WC4SaveOutput, error) {
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
-	if err != nil {
-		log.Fatal("Failed to load save state: ", err)
-		return nil, err
-	}
-	fi, err := inputFile.Stat()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	fileLength := fi.Size()
-	streamReader := io.NewSectionReader(inputFile, int64(0), fileLength)
+// ReadOption configures how ReadSaveFile parses a save file.
+type ReadOption func(*readOptions)
 
-	saveHeader := DeserializeMapHeaderFromBytes(streamReader)
-	allPlayerData := DeserializeCountryDataFromBytes(streamReader, int(saveHeader.CountryCount))
+type readOptions struct {
+	logger *log.Logger
+}
 
-	isConquest := (int(saveHeader.GameMode) == 2)
-	if !isConquest {
-		if saveHeader.UnknownInt7 == 0 {
-			DeserializeUnknownCampaignBlockFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight))
-		}
+// WithLogger prints every decoded record to logger while parsing.
+// Nothing is printed by default.
+func WithLogger(logger *log.Logger) ReadOption {
+	return func(options *readOptions) {
+		options.logger = logger
 	}
+}
 
-	allCityTiles := DeserializeCityTileOwnershipFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight))
-
-	if !isConquest {
-		// required for some maps because the data is shifted
-		if int(saveHeader.MapWidth) * int(saveHeader.MapHeight) != int(saveHeader.UnknownInt10) {
-			unknownBlock := make([]byte, 8)
-			if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-				log.Fatal("Failed to load unknownBlock: ", err)
-			}
-		}
+func ReadSaveFile(inputFilename string, opts ...ReadOption) (*WC4SaveOutput, error) {
+	fileData, err := os.ReadFile(inputFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load save state: %w", err)
 	}
+	return ReadSaveData(fileData, opts...)
+}
 
-	updateFileOffsetMap(fileOffsetMap, streamReader, buildUnitOwnerStartKey())
-	unitOwnerData := DeserializeUnitOwnerDataFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight))
-	updateFileOffsetMap(fileOffsetMap, streamReader, buildUnitOwnerEndKey())
+// ReadSaveData parses a save file that is already in memory.
+// LZ4 compressed saves are detected from the frame magic and decompressed first.
+func ReadSaveData(fileData []byte, opts ...ReadOption) (*WC4SaveOutput, error) {
+	saveData, compression, err := decompressSave(fileData)
+	if err != nil {
+		return nil, err
+	}
+	return parseSaveData(saveData, compression, opts...)
+}
 
-	if !isConquest {
-		if int(saveHeader.MapWidth) * int(saveHeader.MapHeight) != int(saveHeader.UnknownInt10) {
-			unknownBlock := make([]byte, 4)
-			if err := binary.Read(streamReader, binary.LittleEndian, &unknownBlock); err != nil {
-				log.Fatal("Failed to load unknownBlock: ", err)
-			}
-		}
+// parseSaveData parses a save that is already decompressed. compression is kept in the
+// output so the save is written back with the same framing.
+func parseSaveData(saveData []byte, compression *SaveCompression, opts ...ReadOption) (*WC4SaveOutput, error) {
+	options := readOptions{logger: log.New(io.Discard, "", 0)}
+	for _, opt := range opts {
+		opt(&options)
 	}
+	logger := options.logger
 
-	allCities := DeserializeCityDataFromBytes(streamReader, int(saveHeader.CityCount))
-	allUnits := DeserializeUnitDataFromBytes(streamReader, int(saveHeader.UnitCount))
-	DeserializeLandmineDataFromBytes(streamReader, int(saveHeader.LandmineCount))
-	DeserializeUnknownData2FromBytes(streamReader, int(saveHeader.UnknownCount1))
-	DeserializeUnknownData3FromBytes(streamReader, int(saveHeader.UnknownCount2))
-	DeserializeUnknownData4FromBytes(streamReader, int(saveHeader.UnknownCount3))
-	DeserializeUnknownData5FromBytes(streamReader, int(saveHeader.UnknownCount5))
-	DeserializeUnknownData5FromBytes(streamReader, int(saveHeader.UnknownCount6))
-	DeserializeImportantCityDataFromBytes(streamReader, int(saveHeader.ImportantCityCount))
-	DeserializeUnknownData7FromBytes(streamReader, int(saveHeader.UnknownCount9))
-
-	saveOutput := &WC4SaveOutput{
-		SaveHeader: saveHeader,
-		PlayerData:    allPlayerData,
-		CityTiles:     allCityTiles,
-		UnitOwnerData: unitOwnerData,
-		Cities: allCities,
-		Units: allUnits,
+	streamReader := io.NewSectionReader(bytes.NewReader(saveData), int64(0), int64(len(saveData)))
+	saveOutput := &WC4SaveOutput{Compression: compression}
+	for _, section := range saveSections {
+		if err := section.read(streamReader, saveOutput, logger); err != nil {
+			return nil, err
+		}
 	}
 	return saveOutput, nil
 }
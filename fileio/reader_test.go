@@ -0,0 +1,150 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readFixture returns the raw contents of a save in testdata
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	fileData, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileData
+}
+
+// openFixture starts a session on a copy of a save in testdata
+func openFixture(t *testing.T, name string) *Session {
+	t.Helper()
+	inputFilename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(inputFilename, readFixture(t, name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	session, err := Open(inputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// withHeaderField returns a copy of an uncompressed save with one header field replaced
+func withHeaderField(t *testing.T, saveData []byte, fieldName string, value uint32) []byte {
+	t.Helper()
+	header := SaveHeader{}
+	if err := binary.Read(bytes.NewReader(saveData), binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+	reflect.ValueOf(&header).Elem().FieldByName(fieldName).SetUint(uint64(value))
+
+	buffer := &bytes.Buffer{}
+	if err := binary.Write(buffer, binary.LittleEndian, header); err != nil {
+		t.Fatal(err)
+	}
+	return append(buffer.Bytes(), saveData[buffer.Len():]...)
+}
+
+func TestReadSaveDataCorruptCounts(t *testing.T) {
+	testCases := []struct {
+		fixture string
+		field   string
+		section string
+	}{
+		{"conquest.sav", "CountryCount", "country data"},
+		{"campaign.sav", "MapWidth", "campaign block"},
+		{"conquest.sav", "MapWidth", "city tiles"},
+		{"conquest.sav", "MapHeight", "city tiles"},
+		{"campaign.sav", "CityCount", "city data"},
+		{"conquest.sav", "CityCount", "city data"},
+		{"conquest.sav", "UnitCount", "unit data"},
+		{"conquest.sav", "LandmineCount", "landmine data"},
+		{"conquest.sav", "UnknownCount1", "unknown block 2"},
+		{"conquest.sav", "UnknownCount2", "unknown block 3"},
+		{"conquest.sav", "UnknownCount3", "unknown block 4"},
+		{"conquest.sav", "UnknownCount5", "unknown block 5"},
+		{"conquest.sav", "UnknownCount6", "unknown block 5"},
+		{"conquest.sav", "ImportantCityCount", "important cities"},
+		{"conquest.sav", "UnknownCount9", "unknown block 7"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.fixture+"/"+testCase.field, func(t *testing.T) {
+			saveData := withHeaderField(t, readFixture(t, testCase.fixture), testCase.field, 0xFFFFFFFF)
+			_, err := ReadSaveData(saveData)
+			var sectionError *SectionError
+			if !errors.As(err, &sectionError) {
+				t.Fatalf("expected a SectionError, got %v", err)
+			}
+			if sectionError.Section != testCase.section {
+				t.Errorf("expected section %q, got %q", testCase.section, sectionError.Section)
+			}
+		})
+	}
+}
+
+func TestReadSaveDataUnitOwnerCount(t *testing.T) {
+	// the unit owner rows are only reached with a map that fits the city tiles, so
+	// cut the save right after them instead of corrupting the map size
+	saveData := readFixture(t, "conquest.sav")
+	saveOutput, err := ReadSaveData(saveData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitOwnerStart := -1
+	for _, section := range GetSaveLayout(saveOutput) {
+		if section.Name == "unit owners" {
+			unitOwnerStart = section.Start
+		}
+	}
+	_, err = ReadSaveData(saveData[:unitOwnerStart+1])
+	var sectionError *SectionError
+	if !errors.As(err, &sectionError) || sectionError.Section != "unit owner" {
+		t.Fatalf("expected a unit owner SectionError, got %v", err)
+	}
+}
+
+func TestReadGridRowNamesTile(t *testing.T) {
+	// 5 bytes hold the first two tiles of a row of four uint16 tiles and half of the third
+	streamReader := io.NewSectionReader(bytes.NewReader([]byte{1, 0, 2, 0, 3}), 0, 5)
+	row := make([]uint16, 4)
+	err := readGridRow(streamReader, "city tiles", 2, 2, row)
+	var sectionError *SectionError
+	if !errors.As(err, &sectionError) {
+		t.Fatalf("expected a SectionError, got %v", err)
+	}
+	if sectionError.Section != "city tiles (2, 2)" || sectionError.Offset != 4 {
+		t.Errorf("expected city tiles (2, 2) at offset 4, got %v at offset %v", sectionError.Section, sectionError.Offset)
+	}
+
+	streamReader = io.NewSectionReader(bytes.NewReader([]byte{1, 0, 2, 0}), 0, 4)
+	row = make([]uint16, 2)
+	if err := readGridRow(streamReader, "city tiles", 0, 2, row); err != nil {
+		t.Fatal(err)
+	}
+	if row[0] != 1 || row[1] != 2 {
+		t.Errorf("expected [1 2], got %v", row)
+	}
+}
+
+func TestReadSaveDataTruncated(t *testing.T) {
+	for _, fixture := range []string{"conquest.sav", "campaign.sav"} {
+		saveData := readFixture(t, fixture)
+		for length := 0; length < len(saveData); length++ {
+			_, err := ReadSaveData(saveData[:length])
+			var sectionError *SectionError
+			if !errors.As(err, &sectionError) {
+				t.Fatalf("%v cut to %v bytes: expected a SectionError, got %v", fixture, length, err)
+			}
+			if !strings.Contains(err.Error(), "offset") {
+				t.Errorf("%v cut to %v bytes: error %q has no offset", fixture, length, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package fileio
+
+import (
+	"io"
+	"log"
+)
+
+// saveSection describes one section of the uncompressed save. ReadSaveData,
+// serializeSaveFile and GetSaveLayout all walk saveSections, so the order in
+// which sections are read, written and listed is defined in one place.
+type saveSection struct {
+	name    string
+	unknown bool // the meaning of the section is not known
+
+	// read decodes the section into saveOutput, whose SaveHeader is already read.
+	// Sections that are missing from some saves check the header and read nothing.
+	read func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) error
+	// write encodes the section, writing nothing if it is empty
+	write func(w io.Writer, saveOutput *WC4SaveOutput) error
+	// headerCount returns the number of records the header declares and the number
+	// the save holds, nil for sections whose size doesn't come from a header count
+	headerCount func(saveOutput *WC4SaveOutput) (uint32, int)
+
+	// records returns the slice of records in the section and recordName names one
+	// of them in the layout. Sections without records give their size in bytes instead.
+	records    func(saveOutput *WC4SaveOutput) interface{}
+	recordName string
+	size       func(saveOutput *WC4SaveOutput) int
+}
+
+func isConquest(saveHeader SaveHeader) bool {
+	return int(saveHeader.GameMode) == 2
+}
+
+// hasCampaignTiles reports whether campaign and frontier saves store a block per tile before the city tiles
+func hasCampaignTiles(saveHeader SaveHeader) bool {
+	return !isConquest(saveHeader) && saveHeader.UnknownInt7 == 0
+}
+
+// hasTilePadding reports whether the city tiles and unit owners are followed by padding,
+// which some maps need because their data is shifted
+func hasTilePadding(saveHeader SaveHeader) bool {
+	return !isConquest(saveHeader) && int(saveHeader.MapWidth)*int(saveHeader.MapHeight) != int(saveHeader.UnknownInt10)
+}
+
+func readPadding(streamReader *io.SectionReader, section string, size int) ([]byte, error) {
+	padding := make([]byte, size)
+	if err := readSection(streamReader, section, &padding); err != nil {
+		return nil, err
+	}
+	return padding, nil
+}
+
+var saveSections = []saveSection{
+	{
+		name: "header",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.SaveHeader, err = DeserializeMapHeaderFromBytes(streamReader, logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeMapHeaderToBytes(w, saveOutput.SaveHeader)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return []SaveHeader{saveOutput.SaveHeader} },
+		recordName: "header",
+	},
+	{
+		name: "players",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.PlayerData, err = DeserializeCountryDataFromBytes(streamReader, int(saveOutput.SaveHeader.CountryCount), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeCountryDataToBytes(w, saveOutput.PlayerData)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.CountryCount, len(saveOutput.PlayerData)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.PlayerData },
+		recordName: "player",
+	},
+	{
+		name:    "campaign tiles",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveHeader := saveOutput.SaveHeader
+			if !hasCampaignTiles(saveHeader) {
+				return nil
+			}
+			saveOutput.CampaignTiles, err = DeserializeUnknownCampaignBlockFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeUnknownCampaignBlockToBytes(w, saveOutput.CampaignTiles, int(saveOutput.SaveHeader.MapWidth))
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return gridSize(saveOutput.CampaignTiles) },
+	},
+	{
+		name: "city tiles",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveHeader := saveOutput.SaveHeader
+			saveOutput.CityTiles, err = DeserializeCityTileOwnershipFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeCityTileOwnershipToBytes(w, saveOutput.CityTiles, int(saveOutput.SaveHeader.MapWidth))
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.MapHeight, len(saveOutput.CityTiles)
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return gridSize(saveOutput.CityTiles) },
+	},
+	{
+		name:    "city tiles padding",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			if !hasTilePadding(saveOutput.SaveHeader) {
+				return nil
+			}
+			saveOutput.RawCityTilesPadding, err = readPadding(streamReader, "city tiles padding", 8)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "city tiles padding", saveOutput.RawCityTilesPadding)
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return len(saveOutput.RawCityTilesPadding) },
+	},
+	{
+		name: "unit owners",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveHeader := saveOutput.SaveHeader
+			saveOutput.UnitOwnerData, err = DeserializeUnitOwnerDataFromBytes(streamReader, int(saveHeader.MapWidth), int(saveHeader.MapHeight), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeUnitOwnerDataToBytes(w, saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.MapWidth))
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.MapHeight, len(saveOutput.UnitOwnerData)
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return gridSize(saveOutput.UnitOwnerData) },
+	},
+	{
+		name:    "unit owner padding",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			if !hasTilePadding(saveOutput.SaveHeader) {
+				return nil
+			}
+			saveOutput.RawUnitOwnerPadding, err = readPadding(streamReader, "unit owner padding", 4)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unit owner padding", saveOutput.RawUnitOwnerPadding)
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return len(saveOutput.RawUnitOwnerPadding) },
+	},
+	{
+		name: "cities",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.Cities, err = DeserializeCityDataFromBytes(streamReader, int(saveOutput.SaveHeader.CityCount), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeCityDataToBytes(w, saveOutput.Cities)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.CityCount, len(saveOutput.Cities)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.Cities },
+		recordName: "city",
+	},
+	{
+		name: "units",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.Units, err = DeserializeUnitDataFromBytes(streamReader, int(saveOutput.SaveHeader.UnitCount), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return SerializeUnitDataToBytes(w, saveOutput.Units)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnitCount, len(saveOutput.Units)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.Units },
+		recordName: "unit",
+	},
+	{
+		name: "landmines",
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.Landmines, err = DeserializeLandmineDataFromBytes(streamReader, int(saveOutput.SaveHeader.LandmineCount), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "landmines", saveOutput.Landmines)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.LandmineCount, len(saveOutput.Landmines)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.Landmines },
+		recordName: "landmine",
+	},
+	{
+		name:    "unknown block 2",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData2, err = DeserializeUnknownData2FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount1), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 2", saveOutput.UnknownData2)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount1, len(saveOutput.UnknownData2)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData2 },
+		recordName: "record",
+	},
+	{
+		name:    "unknown block 3",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData3, err = DeserializeUnknownData3FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount2), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 3", saveOutput.UnknownData3)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount2, len(saveOutput.UnknownData3)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData3 },
+		recordName: "record",
+	},
+	{
+		name:    "unknown block 4",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData4, err = DeserializeUnknownData4FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount3), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 4", saveOutput.UnknownData4)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount3, len(saveOutput.UnknownData4)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData4 },
+		recordName: "record",
+	},
+	{
+		name:    "unknown block 5",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData5, err = DeserializeUnknownData5FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount5), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 5", saveOutput.UnknownData5)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount5, len(saveOutput.UnknownData5)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData5 },
+		recordName: "record",
+	},
+	{
+		name:    "unknown block 6",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData6, err = DeserializeUnknownData5FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount6), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 6", saveOutput.UnknownData6)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount6, len(saveOutput.UnknownData6)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData6 },
+		recordName: "record",
+	},
+	{
+		name:    "important cities",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.ImportantCities, err = DeserializeImportantCityDataFromBytes(streamReader, int(saveOutput.SaveHeader.ImportantCityCount), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "important cities", saveOutput.ImportantCities)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.ImportantCityCount, len(saveOutput.ImportantCities)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.ImportantCities },
+		recordName: "important city",
+	},
+	{
+		name:    "unknown block 7",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.UnknownData7, err = DeserializeUnknownData7FromBytes(streamReader, int(saveOutput.SaveHeader.UnknownCount9), logger)
+			return err
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "unknown block 7", saveOutput.UnknownData7)
+		},
+		headerCount: func(saveOutput *WC4SaveOutput) (uint32, int) {
+			return saveOutput.SaveHeader.UnknownCount9, len(saveOutput.UnknownData7)
+		},
+		records:    func(saveOutput *WC4SaveOutput) interface{} { return saveOutput.UnknownData7 },
+		recordName: "record",
+	},
+	{
+		// anything after the last known section is kept so the file can be written back unchanged
+		name:    "trailing data",
+		unknown: true,
+		read: func(streamReader *io.SectionReader, saveOutput *WC4SaveOutput, logger *log.Logger) (err error) {
+			saveOutput.TrailingData, err = io.ReadAll(streamReader)
+			if err != nil {
+				return &SectionError{Section: "trailing data", Offset: streamReader.Size(), Err: err}
+			}
+			return nil
+		},
+		write: func(w io.Writer, saveOutput *WC4SaveOutput) error {
+			return writeSection(w, "trailing data", saveOutput.TrailingData)
+		},
+		size: func(saveOutput *WC4SaveOutput) int { return len(saveOutput.TrailingData) },
+	},
+}
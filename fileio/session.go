@@ -0,0 +1,252 @@
+package fileio
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	NoTileOwner      = 255
+	CurrencyCount    = 3
+	CityTechCount    = 6
+	MaxCityTechLevel = 4
+)
+
+// Session stages edits to a save in memory. The setters change Save and
+// Commit writes the whole file once, compressed the same way it was read.
+type Session struct {
+	Path string
+	Save *WC4SaveOutput
+
+	// AllowStaleImportantCities lets TransferTile and TransferPlayer hand over cities
+	// even though the undecoded important city records are left unchanged
+	AllowStaleImportantCities bool
+	// AllowStaleUnitReferences lets RemoveUnits remove units even though the undecoded
+	// blocks that may refer to units by index are left unchanged
+	AllowStaleUnitReferences bool
+
+	// uncompressed contents of the file when it was opened or last committed
+	originalData []byte
+}
+
+// Open reads a save file and starts an edit session on it
+func Open(inputFilename string, opts ...ReadOption) (*Session, error) {
+	saveData, compression, err := readSaveImage(inputFilename)
+	if err != nil {
+		return nil, err
+	}
+	saveOutput, err := parseSaveData(saveData, compression, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		Path:         inputFilename,
+		Save:         saveOutput,
+		originalData: saveData,
+	}, nil
+}
+
+func (s *Session) serialize() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := serializeSaveFile(buffer, s.Save); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Changes returns the ranges of the uncompressed save that Commit would rewrite
+func (s *Session) Changes() ([]ByteRange, error) {
+	saveData, err := s.serialize()
+	if err != nil {
+		return nil, err
+	}
+	return CompareSaveData(s.originalData, saveData), nil
+}
+
+// Commit writes every staged edit to the file in one atomic write. Nothing is
+// written if the save is unchanged. Save is parsed again afterwards so that it
+// matches the new file exactly.
+func (s *Session) Commit() error {
+	saveData, err := s.serialize()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(saveData, s.originalData) {
+		return nil
+	}
+	if err := writeSaveImage(s.Path, saveData, s.Save.Compression); err != nil {
+		return err
+	}
+
+	saveOutput, err := parseSaveData(saveData, s.Save.Compression)
+	if err != nil {
+		return err
+	}
+	s.Save = saveOutput
+	s.originalData = saveData
+	return nil
+}
+
+func (s *Session) checkPlayer(player int) error {
+	if player < 0 || player >= len(s.Save.PlayerData) {
+		return fmt.Errorf("player must be between 0 and %v, got %v", len(s.Save.PlayerData)-1, player)
+	}
+	return nil
+}
+
+func (s *Session) checkUnit(unit int) error {
+	if unit < 0 || unit >= len(s.Save.Units) {
+		return fmt.Errorf("unit must be between 0 and %v, got %v", len(s.Save.Units)-1, unit)
+	}
+	return nil
+}
+
+func (s *Session) checkCity(city int) error {
+	if city < 0 || city >= len(s.Save.Cities) {
+		return fmt.Errorf("city must be between 0 and %v, got %v", len(s.Save.Cities)-1, city)
+	}
+	return nil
+}
+
+// SetCurrency sets one of the three currencies of a player
+func (s *Session) SetCurrency(player int, currency int, amount int) error {
+	if err := s.checkPlayer(player); err != nil {
+		return err
+	}
+	if currency < 0 || currency >= CurrencyCount {
+		return fmt.Errorf("currency must be between 0 and %v, got %v", CurrencyCount-1, currency)
+	}
+	if amount < 0 || int64(amount) > math.MaxUint32 {
+		return fmt.Errorf("currency amount must be between 0 and %v, got %v", uint32(math.MaxUint32), amount)
+	}
+	s.Save.PlayerData[player].Currency[currency] = uint32(amount)
+	return nil
+}
+
+// SetPlayerTeam moves a player onto another team
+func (s *Session) SetPlayerTeam(player int, teamId int) error {
+	if err := s.checkPlayer(player); err != nil {
+		return err
+	}
+	if teamId < 0 || int64(teamId) > math.MaxUint32 {
+		return fmt.Errorf("team must be between 0 and %v, got %v", uint32(math.MaxUint32), teamId)
+	}
+	s.Save.PlayerData[player].TeamId = uint32(teamId)
+	return nil
+}
+
+// SetUnitHealth sets the current health of a unit, which can't exceed its max health
+func (s *Session) SetUnitHealth(unit int, health int) error {
+	if err := s.checkUnit(unit); err != nil {
+		return err
+	}
+	maxHealth := int(s.Save.Units[unit].MaxHealth)
+	if health < 0 || health > maxHealth {
+		return fmt.Errorf("health of unit %v must be between 0 and %v, got %v", unit, maxHealth, health)
+	}
+	s.Save.Units[unit].CurrentHealth = uint16(health)
+	return nil
+}
+
+// RestoreAllies heals every unit on the same team as player, including its own, to
+// max health and returns the indices of the units whose health changed
+func (s *Session) RestoreAllies(player int) ([]int, error) {
+	if err := s.checkPlayer(player); err != nil {
+		return nil, err
+	}
+	playerTeamId := s.Save.PlayerData[player].TeamId
+	gameMode := int(s.Save.SaveHeader.GameMode)
+
+	healedUnits := make([]int, 0)
+	for i, unit := range s.Save.Units {
+		row, col := ConvertCoordinates(int(unit.CoordinateCode), s.Save.UnitOwnerData, gameMode)
+		owner := GetTileOwner(s.Save, row, col)
+		if owner < 0 || owner >= len(s.Save.PlayerData) || s.Save.PlayerData[owner].TeamId != playerTeamId {
+			continue
+		}
+		if unit.CurrentHealth == unit.MaxHealth {
+			continue
+		}
+		if err := s.SetUnitHealth(i, int(unit.MaxHealth)); err != nil {
+			return healedUnits, err
+		}
+		healedUnits = append(healedUnits, i)
+	}
+	return healedUnits, nil
+}
+
+// SetCityTechLevel sets one of the six tech levels of a city
+func (s *Session) SetCityTechLevel(city int, category int, level int) error {
+	if err := s.checkCity(city); err != nil {
+		return err
+	}
+	if category < 0 || category >= CityTechCount {
+		return fmt.Errorf("tech category must be between 0 and %v, got %v", CityTechCount-1, category)
+	}
+	if level < 0 || level > MaxCityTechLevel {
+		return fmt.Errorf("tech level must be between 0 and %v, got %v", MaxCityTechLevel, level)
+	}
+	s.Save.Cities[city].TechLevels[category] = byte(level)
+	return nil
+}
+
+// SetTileOwner sets the owner byte of a tile to a player index or NoTileOwner
+func (s *Session) SetTileOwner(x int, y int, owner int) error {
+	unitOwnerData := s.Save.UnitOwnerData
+	if y < 0 || y >= len(unitOwnerData) || x < 0 || x >= len(unitOwnerData[y]) {
+		return fmt.Errorf("tile (x: %v, y: %v) is outside the %vx%v map", x, y, s.Save.SaveHeader.MapWidth, s.Save.SaveHeader.MapHeight)
+	}
+	if owner != NoTileOwner {
+		if err := s.checkPlayer(owner); err != nil {
+			return err
+		}
+	}
+	unitOwnerData[y][x] = byte(owner)
+	return nil
+}
+
+// SetPlayerField sets any integer field of a player record and returns the old value
+func (s *Session) SetPlayerField(player int, fieldName string, value int64) (int64, error) {
+	if err := s.checkPlayer(player); err != nil {
+		return 0, err
+	}
+	return setRecordField(&s.Save.PlayerData[player], fieldName, value)
+}
+
+// SetCityField sets any integer field of a city record and returns the old value
+func (s *Session) SetCityField(city int, fieldName string, value int64) (int64, error) {
+	if err := s.checkCity(city); err != nil {
+		return 0, err
+	}
+	return setRecordField(&s.Save.Cities[city], fieldName, value)
+}
+
+// SetUnitField sets any integer field of a unit record and returns the old value
+func (s *Session) SetUnitField(unit int, fieldName string, value int64) (int64, error) {
+	if err := s.checkUnit(unit); err != nil {
+		return 0, err
+	}
+	return setRecordField(&s.Save.Units[unit], fieldName, value)
+}
+
+func setRecordField(recordPtr interface{}, fieldName string, value int64) (int64, error) {
+	field, err := LookupRecordField(reflect.ValueOf(recordPtr).Elem().Interface(), fieldName)
+	if err != nil {
+		return 0, err
+	}
+	oldValue := field.GetValue(recordPtr)
+	var oldInt int64
+	switch oldValue.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		oldInt = oldValue.Int()
+	default:
+		oldInt = int64(oldValue.Uint())
+	}
+
+	if err := field.SetValue(recordPtr, value); err != nil {
+		return 0, err
+	}
+	return oldInt, nil
+}
@@ -0,0 +1,230 @@
+package fileio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/gamedata"
+)
+
+// ErrUnitReferencesNotDecoded is returned when units would be removed from a save with
+// records in the unknown blocks or important cities. Those records may refer to units by
+// their index, which shifts for every unit after a removed one, but their format is not
+// decoded, so they can't be updated.
+var ErrUnitReferencesNotDecoded = errors.New("records that are not decoded may refer to units by index, which removing a unit changes")
+
+// NewUnit describes a unit to add with Session.AddUnit. The new unit copies the
+// record of the first unit of the same type in the save, so fields that are not
+// decoded yet keep values the game accepts for that type.
+type NewUnit struct {
+	UnitType int
+	X        int // column
+	Y        int // row
+	Owner    int
+	// MaxHealth and Level replace the copied values unless they are 0. MaxHealth
+	// is required if the save has no unit of the same type to copy.
+	MaxHealth int
+	Level     int
+}
+
+func (s *Session) checkTileInMap(x int, y int) error {
+	unitOwnerData := s.Save.UnitOwnerData
+	if y < 0 || y >= len(unitOwnerData) || x < 0 || x >= len(unitOwnerData[y]) {
+		return fmt.Errorf("tile (x: %v, y: %v) is outside the %vx%v map", x, y, s.Save.SaveHeader.MapWidth, s.Save.SaveHeader.MapHeight)
+	}
+	return nil
+}
+
+// FindUnitAt returns the index of the unit on a tile or -1
+func (s *Session) FindUnitAt(x int, y int) int {
+	gameMode := int(s.Save.SaveHeader.GameMode)
+	for i, unit := range s.Save.Units {
+		row, col := ConvertCoordinates(int(unit.CoordinateCode), s.Save.UnitOwnerData, gameMode)
+		if row == y && col == x {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkTileFree returns an error if a unit, city or owner byte already occupies the tile
+func (s *Session) checkTileFree(x int, y int) error {
+	if err := s.checkTileInMap(x, y); err != nil {
+		return err
+	}
+	if unitIndex := s.FindUnitAt(x, y); unitIndex >= 0 {
+		return fmt.Errorf("tile (x: %v, y: %v) is occupied by unit %v", x, y, unitIndex)
+	}
+	if cityIndex := s.findCityAt(y, x); cityIndex >= 0 {
+		return fmt.Errorf("tile (x: %v, y: %v) is occupied by city %v", x, y, cityIndex)
+	}
+	if owner := s.Save.UnitOwnerData[y][x]; owner != NoTileOwner {
+		return fmt.Errorf("tile (x: %v, y: %v) is already owned by player %v", x, y, owner)
+	}
+	return nil
+}
+
+// AddUnit appends a unit record, updates UnitCount and marks the tile as owned
+// by the unit's owner. The tile must be free. The record is copied from a unit of
+// the same type for the values that belong to the type, such as max health, level
+// and movement. What the copied unit went through is reset as for a unit that was
+// just built: it has full health, no experience, no morale effect and no general,
+// since a general belongs to one unit. Returns the index of the new unit.
+func (s *Session) AddUnit(newUnit NewUnit) (int, error) {
+	if newUnit.UnitType < 0 || newUnit.UnitType > math.MaxUint8 {
+		return -1, fmt.Errorf("unit type must be between 0 and %v, got %v", math.MaxUint8, newUnit.UnitType)
+	}
+	if newUnit.UnitType == gamedata.CityUnitType {
+		return -1, fmt.Errorf("unit type %v is a city, which also needs a city record and can't be added as a unit", newUnit.UnitType)
+	}
+	if newUnit.MaxHealth < 0 || newUnit.MaxHealth > math.MaxUint16 {
+		return -1, fmt.Errorf("max health must be between 1 and %v, or 0 to copy it, got %v", math.MaxUint16, newUnit.MaxHealth)
+	}
+	if newUnit.Level < 0 || newUnit.Level > math.MaxUint8 {
+		return -1, fmt.Errorf("level must be between 0 and %v, got %v", math.MaxUint8, newUnit.Level)
+	}
+	if err := s.checkPlayer(newUnit.Owner); err != nil {
+		return -1, err
+	}
+	if err := s.checkTileFree(newUnit.X, newUnit.Y); err != nil {
+		return -1, err
+	}
+	coordinateCode := BuildCoordinateCode(newUnit.Y, newUnit.X, s.Save.UnitOwnerData, int(s.Save.SaveHeader.GameMode))
+	if coordinateCode > math.MaxUint16 {
+		return -1, fmt.Errorf("tile (x: %v, y: %v) can't be stored in a coordinate code", newUnit.X, newUnit.Y)
+	}
+
+	unit, ok := s.findUnitOfType(newUnit.UnitType)
+	if !ok && newUnit.MaxHealth == 0 {
+		return -1, fmt.Errorf("no unit of type %v in the save to copy, give the max health", newUnit.UnitType)
+	}
+	unit.CoordinateCode = uint16(coordinateCode)
+	unit.UnitType = uint8(newUnit.UnitType)
+	if newUnit.MaxHealth > 0 {
+		unit.MaxHealth = uint16(newUnit.MaxHealth)
+	}
+	if newUnit.Level > 0 {
+		unit.Level = uint8(newUnit.Level)
+	}
+	unit.CurrentHealth = unit.MaxHealth
+	unit.GeneralId = 0
+	unit.GeneralMilitaryRank = 0
+	unit.GeneralTitle = 0
+	unit.GeneralBadges = [gamedata.GeneralBadgeCount]byte{}
+	unit.GeneralSkillLevels = [gamedata.GeneralSkillCount]byte{}
+	unit.Experience = 0
+	unit.MoraleValue = 0
+	unit.MoraleTurnsLeft = 0
+
+	s.Save.Units = append(s.Save.Units, unit)
+	s.Save.SaveHeader.UnitCount = uint32(len(s.Save.Units))
+	s.Save.UnitOwnerData[newUnit.Y][newUnit.X] = byte(newUnit.Owner)
+	return len(s.Save.Units) - 1, nil
+}
+
+func (s *Session) findUnitOfType(unitType int) (UnitData, bool) {
+	for _, unit := range s.Save.Units {
+		if int(unit.UnitType) == unitType {
+			return unit, true
+		}
+	}
+	return UnitData{}, false
+}
+
+// undecodedRecordSections returns the unknown sections of the save that hold records
+func (s *Session) undecodedRecordSections() []string {
+	sectionNames := make([]string, 0)
+	for _, section := range saveSections {
+		if section.unknown && section.records != nil && reflect.ValueOf(section.records(s.Save)).Len() > 0 {
+			sectionNames = append(sectionNames, section.name)
+		}
+	}
+	return sectionNames
+}
+
+// RemoveUnits drops unit records and updates UnitCount. The owner byte of a tile is
+// cleared unless a remaining unit or a city still stands on it. Generals are part of
+// the unit record and are removed with it. Cities can't be removed because they also
+// have a city record.
+//
+// If the unknown blocks or important cities hold any records, RemoveUnits returns
+// ErrUnitReferencesNotDecoded unless AllowStaleUnitReferences is set.
+func (s *Session) RemoveUnits(unitIndices []int) error {
+	removed := make(map[int]bool)
+	for _, unitIndex := range unitIndices {
+		if err := s.checkUnit(unitIndex); err != nil {
+			return err
+		}
+		if s.Save.Units[unitIndex].UnitType == gamedata.CityUnitType {
+			return fmt.Errorf("unit %v is a city and can't be removed", unitIndex)
+		}
+		removed[unitIndex] = true
+	}
+	if sectionNames := s.undecodedRecordSections(); len(sectionNames) > 0 && len(removed) > 0 && !s.AllowStaleUnitReferences {
+		return fmt.Errorf("can't remove units while the save has %v: %w", strings.Join(sectionNames, ", "), ErrUnitReferencesNotDecoded)
+	}
+
+	gameMode := int(s.Save.SaveHeader.GameMode)
+	remainingUnits := make([]UnitData, 0, len(s.Save.Units)-len(removed))
+	clearedTiles := make([][2]int, 0)
+	for i, unit := range s.Save.Units {
+		if !removed[i] {
+			remainingUnits = append(remainingUnits, unit)
+			continue
+		}
+		row, col := ConvertCoordinates(int(unit.CoordinateCode), s.Save.UnitOwnerData, gameMode)
+		clearedTiles = append(clearedTiles, [2]int{row, col})
+	}
+	s.Save.Units = remainingUnits
+	s.Save.SaveHeader.UnitCount = uint32(len(remainingUnits))
+
+	for _, tile := range clearedTiles {
+		row, col := tile[0], tile[1]
+		if row < 0 || row >= len(s.Save.UnitOwnerData) || col < 0 || col >= len(s.Save.UnitOwnerData[row]) {
+			continue
+		}
+		if s.FindUnitAt(col, row) < 0 && s.findCityAt(row, col) < 0 {
+			s.Save.UnitOwnerData[row][col] = NoTileOwner
+		}
+	}
+	return nil
+}
+
+// MoveUnit moves a unit to a free tile. The coordinate code is built with the same
+// conquest row offset that ConvertCoordinates removes, and the owner byte moves with
+// the unit. The old tile keeps its owner if a city stands on it.
+//
+// Terrain is not decoded from the save yet, so a move from land to water is not rejected.
+func (s *Session) MoveUnit(unitIndex int, x int, y int) error {
+	if err := s.checkUnit(unitIndex); err != nil {
+		return err
+	}
+	unit := &s.Save.Units[unitIndex]
+	if unit.UnitType == gamedata.CityUnitType {
+		return fmt.Errorf("unit %v is a city and can't be moved", unitIndex)
+	}
+	if err := s.checkTileFree(x, y); err != nil {
+		return err
+	}
+
+	gameMode := int(s.Save.SaveHeader.GameMode)
+	oldRow, oldCol := ConvertCoordinates(int(unit.CoordinateCode), s.Save.UnitOwnerData, gameMode)
+	if oldRow < 0 || oldRow >= len(s.Save.UnitOwnerData) || oldCol < 0 || oldCol >= len(s.Save.UnitOwnerData[oldRow]) {
+		return fmt.Errorf("unit %v is outside the map at row %v, column %v", unitIndex, oldRow, oldCol)
+	}
+	coordinateCode := BuildCoordinateCode(y, x, s.Save.UnitOwnerData, gameMode)
+	if coordinateCode > math.MaxUint16 {
+		return fmt.Errorf("tile (x: %v, y: %v) can't be stored in a coordinate code", x, y)
+	}
+
+	owner := s.Save.UnitOwnerData[oldRow][oldCol]
+	unit.CoordinateCode = uint16(coordinateCode)
+	s.Save.UnitOwnerData[y][x] = owner
+	if s.findCityAt(oldRow, oldCol) < 0 {
+		s.Save.UnitOwnerData[oldRow][oldCol] = NoTileOwner
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package fileio
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAddUnitCopiesTemplate(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	session.Save.Units[1].Experience = 40
+	session.Save.Units[1].MoraleValue = 3
+	session.Save.Units[1].MoraleTurnsLeft = 2
+	template := session.Save.Units[1]
+	if template.GeneralId == 0 {
+		t.Fatal("expected unit 1 of the fixture to have a general")
+	}
+
+	index, err := session.AddUnit(NewUnit{UnitType: int(template.UnitType), X: 2, Y: 2, Owner: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := template
+	expected.CoordinateCode = uint16(BuildCoordinateCode(2, 2, session.Save.UnitOwnerData, int(session.Save.SaveHeader.GameMode)))
+	expected.CurrentHealth = template.MaxHealth
+	expected.GeneralId = 0
+	expected.GeneralMilitaryRank = 0
+	expected.GeneralTitle = 0
+	expected.GeneralBadges = [3]byte{}
+	expected.GeneralSkillLevels = [5]byte{}
+	expected.Experience = 0
+	expected.MoraleValue = 0
+	expected.MoraleTurnsLeft = 0
+	if unit := session.Save.Units[index]; unit != expected {
+		t.Errorf("expected %+v, got %+v", expected, unit)
+	}
+	if owner := session.Save.UnitOwnerData[2][2]; owner != 2 {
+		t.Errorf("expected the tile to be owned by player 2, got %v", owner)
+	}
+	if int(session.Save.SaveHeader.UnitCount) != len(session.Save.Units) {
+		t.Errorf("UnitCount is %v, the save has %v units", session.Save.SaveHeader.UnitCount, len(session.Save.Units))
+	}
+}
+
+func TestAddUnitInvalid(t *testing.T) {
+	testCases := []NewUnit{
+		{UnitType: 5, X: 2, Y: 2, Level: 256},
+		{UnitType: 5, X: 2, Y: 2, Level: -1},
+		{UnitType: 5, X: 2, Y: 2, MaxHealth: math.MaxUint16 + 1},
+		{UnitType: 5, X: 2, Y: 2, MaxHealth: -1},
+		{UnitType: 256, X: 2, Y: 2, MaxHealth: 100},
+		{UnitType: 39, X: 2, Y: 2, MaxHealth: 100},
+		{UnitType: 200, X: 2, Y: 2}, // no unit of the type to copy
+		{UnitType: 5, X: 3, Y: 0},   // occupied
+		{UnitType: 5, X: 2, Y: 2, Owner: 3},
+	}
+	for _, newUnit := range testCases {
+		session := openFixture(t, "conquest.sav")
+		unitCount := len(session.Save.Units)
+		if _, err := session.AddUnit(newUnit); err == nil {
+			t.Errorf("%+v: expected an error", newUnit)
+		}
+		if len(session.Save.Units) != unitCount {
+			t.Errorf("%+v: the unit was added despite the error", newUnit)
+		}
+	}
+}
+
+func TestAddUnitCoordinateCodeOverflow(t *testing.T) {
+	unitOwnerData := make([][]byte, 300)
+	for i := range unitOwnerData {
+		unitOwnerData[i] = make([]byte, 300)
+		for j := range unitOwnerData[i] {
+			unitOwnerData[i][j] = NoTileOwner
+		}
+	}
+	session := &Session{Save: &WC4SaveOutput{
+		PlayerData:    make([]CountryData, 1),
+		UnitOwnerData: unitOwnerData,
+	}}
+	if _, err := session.AddUnit(NewUnit{UnitType: 5, X: 299, Y: 299, MaxHealth: 100}); err == nil {
+		t.Fatal("expected an error for a tile past the largest coordinate code")
+	}
+	if _, err := session.AddUnit(NewUnit{UnitType: 5, X: 0, Y: 1, MaxHealth: 100}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveUnitsRefusesUndecodedReferences(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	before, err := session.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.RemoveUnits([]int{0}); !errors.Is(err, ErrUnitReferencesNotDecoded) {
+		t.Fatalf("expected ErrUnitReferencesNotDecoded, got %v", err)
+	}
+	after, err := session.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("the refused removal changed the save")
+	}
+
+	session.AllowStaleUnitReferences = true
+	if err := session.RemoveUnits([]int{0}); err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Save.Units) != 3 || session.Save.SaveHeader.UnitCount != 3 {
+		t.Errorf("expected 3 units, got %v with UnitCount %v", len(session.Save.Units), session.Save.SaveHeader.UnitCount)
+	}
+	if owner := session.Save.UnitOwnerData[0][3]; owner != NoTileOwner {
+		t.Errorf("expected the removed unit's tile to be cleared, got owner %v", owner)
+	}
+}
+
+func TestRemoveUnitsWithoutUndecodedRecords(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	saveOutput := session.Save
+	saveOutput.UnknownData2, saveOutput.UnknownData3, saveOutput.UnknownData4 = nil, nil, nil
+	saveOutput.UnknownData5, saveOutput.UnknownData6, saveOutput.UnknownData7 = nil, nil, nil
+	saveOutput.ImportantCities = nil
+	if err := session.RemoveUnits([]int{0}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreAllies(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+
+	// players 0 and 2 are on team 0, player 1 on team 1
+	healedUnits, err := session.RestoreAllies(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(healedUnits, []int{0, 2}) {
+		t.Errorf("expected units 0 and 2 to be healed, got %v", healedUnits)
+	}
+	for _, unitIndex := range []int{0, 2} {
+		if unit := session.Save.Units[unitIndex]; unit.CurrentHealth != unit.MaxHealth {
+			t.Errorf("unit %v has %v of %v health", unitIndex, unit.CurrentHealth, unit.MaxHealth)
+		}
+	}
+	if unit := session.Save.Units[1]; unit.CurrentHealth != 80 {
+		t.Errorf("the enemy unit was healed to %v", unit.CurrentHealth)
+	}
+
+	healedUnits, err = session.RestoreAllies(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(healedUnits) != 0 {
+		t.Errorf("healing again should change nothing, got %v", healedUnits)
+	}
+	if _, err := session.RestoreAllies(3); err == nil {
+		t.Error("expected an error for a player that doesn't exist")
+	}
+}
+
+func TestMoveUnit(t *testing.T) {
+	// conquest saves store the row 2 lower in the coordinate code
+	for fixture, expectedCode := range map[string]uint16{"conquest.sav": (2+2)*6 + 2, "campaign.sav": 2*6 + 2} {
+		t.Run(fixture, func(t *testing.T) {
+			session := openFixture(t, fixture)
+			if err := session.MoveUnit(1, 2, 2); err != nil {
+				t.Fatal(err)
+			}
+			if code := session.Save.Units[1].CoordinateCode; code != expectedCode {
+				t.Errorf("expected coordinate code %v, got %v", expectedCode, code)
+			}
+			if owner := session.Save.UnitOwnerData[2][2]; owner != 1 {
+				t.Errorf("expected the new tile to be owned by player 1, got %v", owner)
+			}
+			if owner := session.Save.UnitOwnerData[4][0]; owner != NoTileOwner {
+				t.Errorf("expected the old tile to have no owner, got %v", owner)
+			}
+
+			if err := session.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			reopened, err := Open(session.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unitIndex := reopened.FindUnitAt(2, 2); unitIndex != 1 {
+				t.Errorf("expected unit 1 at (x: 2, y: 2) after saving, got %v", unitIndex)
+			}
+		})
+	}
+}
+
+func TestMoveUnitFromCityTile(t *testing.T) {
+	session := openFixture(t, "conquest.sav")
+	// put unit 0 of player 0 on the center of city 0, which player 0 owns
+	session.Save.Units[0].CoordinateCode = session.Save.Cities[0].CoordinateCode
+	if err := session.MoveUnit(0, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if owner := session.Save.UnitOwnerData[1][1]; owner != 0 {
+		t.Errorf("expected the city tile to stay owned by player 0, got %v", owner)
+	}
+	if owner := session.Save.UnitOwnerData[2][2]; owner != 0 {
+		t.Errorf("expected the new tile to be owned by player 0, got %v", owner)
+	}
+}
+
+func TestMoveUnitInvalid(t *testing.T) {
+	testCases := []struct {
+		unitIndex int
+		x         int
+		y         int
+	}{
+		{0, 0, 4},  // occupied by unit 1
+		{0, 4, 3},  // occupied by city 1
+		{0, 6, 0},  // outside the map
+		{0, -1, 0}, // outside the map
+		{0, 2, 5},  // outside the map
+		{4, 2, 2},  // no such unit
+		{-1, 2, 2}, // no such unit
+		{3, 2, 2},  // cities can't move
+	}
+	for _, testCase := range testCases {
+		session := openFixture(t, "conquest.sav")
+		if err := session.MoveUnit(testCase.unitIndex, testCase.x, testCase.y); err == nil {
+			t.Errorf("%+v: expected an error", testCase)
+		}
+		if changes, err := session.Changes(); err != nil || len(changes) > 0 {
+			t.Errorf("%+v: expected no changes, got %v (%v)", testCase, changes, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package fileio
+
+import (
+	"fmt"
+)
+
+// ValidationIssue is a broken invariant found by ValidateSave
+type ValidationIssue struct {
+	Section string
+	Index   int // record index within the section, -1 if the issue is not about one record
+	Message string
+}
+
+func (issue ValidationIssue) String() string {
+	if issue.Index < 0 {
+		return fmt.Sprintf("%v: %v", issue.Section, issue.Message)
+	}
+	return fmt.Sprintf("%v %v: %v", issue.Section, issue.Index, issue.Message)
+}
+
+type saveValidator struct {
+	saveOutput *WC4SaveOutput
+	issues     []ValidationIssue
+}
+
+func (v *saveValidator) report(section string, index int, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{Section: section, Index: index, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkCoordinate reports a coordinate code that doesn't land on the map and returns the tile otherwise
+func (v *saveValidator) checkCoordinate(section string, index int, coordinateCode uint16) (int, int, bool) {
+	saveOutput := v.saveOutput
+	if len(saveOutput.UnitOwnerData) == 0 || len(saveOutput.UnitOwnerData[0]) == 0 {
+		return 0, 0, false
+	}
+	row, col := ConvertCoordinates(int(coordinateCode), saveOutput.UnitOwnerData, int(saveOutput.SaveHeader.GameMode))
+	if row < 0 || row >= len(saveOutput.UnitOwnerData) || col < 0 || col >= len(saveOutput.UnitOwnerData[row]) {
+		v.report(section, index, "coordinate code %v is row %v, column %v, which is outside the %vx%v map",
+			coordinateCode, row, col, saveOutput.SaveHeader.MapWidth, saveOutput.SaveHeader.MapHeight)
+		return 0, 0, false
+	}
+	return row, col, true
+}
+
+func (v *saveValidator) checkCount(section string, headerField string, headerCount uint32, actualCount int) {
+	if int(headerCount) != actualCount {
+		v.report(section, -1, "header %v is %v, but there are %v records", headerField, headerCount, actualCount)
+	}
+}
+
+func (v *saveValidator) validateCounts() {
+	saveOutput := v.saveOutput
+	saveHeader := saveOutput.SaveHeader
+	v.checkCount("players", "CountryCount", saveHeader.CountryCount, len(saveOutput.PlayerData))
+	v.checkCount("cities", "CityCount", saveHeader.CityCount, len(saveOutput.Cities))
+	v.checkCount("units", "UnitCount", saveHeader.UnitCount, len(saveOutput.Units))
+	v.checkCount("landmines", "LandmineCount", saveHeader.LandmineCount, len(saveOutput.Landmines))
+	v.checkCount("important cities", "ImportantCityCount", saveHeader.ImportantCityCount, len(saveOutput.ImportantCities))
+}
+
+func (v *saveValidator) validateTiles() {
+	saveOutput := v.saveOutput
+	mapWidth := int(saveOutput.SaveHeader.MapWidth)
+	mapHeight := int(saveOutput.SaveHeader.MapHeight)
+
+	if len(saveOutput.UnitOwnerData) != mapHeight {
+		v.report("unit owners", -1, "%v rows, but the map height is %v", len(saveOutput.UnitOwnerData), mapHeight)
+	}
+	for row, ownerRow := range saveOutput.UnitOwnerData {
+		if len(ownerRow) != mapWidth {
+			v.report("unit owners", -1, "row %v has %v tiles, but the map width is %v", row, len(ownerRow), mapWidth)
+		}
+		for col, owner := range ownerRow {
+			if owner != NoTileOwner && int(owner) >= len(saveOutput.PlayerData) {
+				v.report("unit owners", -1, "tile at row %v, column %v is owned by player %v, but there are only %v players",
+					row, col, owner, len(saveOutput.PlayerData))
+			}
+		}
+	}
+
+	cityCoordinates := make(map[uint16]bool)
+	for _, city := range saveOutput.Cities {
+		cityCoordinates[city.CoordinateCode] = true
+	}
+	if len(saveOutput.CityTiles) != mapHeight {
+		v.report("city tiles", -1, "%v rows, but the map height is %v", len(saveOutput.CityTiles), mapHeight)
+	}
+	for row, cityRow := range saveOutput.CityTiles {
+		if len(cityRow) != mapWidth {
+			v.report("city tiles", -1, "row %v has %v tiles, but the map width is %v", row, len(cityRow), mapWidth)
+		}
+		for col, cityCoordinate := range cityRow {
+			// 0 and 0xFFFF mark tiles that don't belong to a city
+			if cityCoordinate == 0 || cityCoordinate == 0xFFFF || cityCoordinates[cityCoordinate] {
+				continue
+			}
+			v.report("city tiles", -1, "tile at row %v, column %v belongs to coordinate code %v, where there is no city", row, col, cityCoordinate)
+		}
+	}
+}
+
+func (v *saveValidator) validateCities() {
+	for i, city := range v.saveOutput.Cities {
+		v.checkCoordinate("city", i, city.CoordinateCode)
+	}
+}
+
+func (v *saveValidator) validateUnits() {
+	saveOutput := v.saveOutput
+	generalIds := make(map[uint16]int)
+	for i, unit := range saveOutput.Units {
+		if row, col, ok := v.checkCoordinate("unit", i, unit.CoordinateCode); ok {
+			if saveOutput.UnitOwnerData[row][col] == NoTileOwner {
+				v.report("unit", i, "tile at row %v, column %v has no owner", row, col)
+			}
+		}
+		if unit.CurrentHealth > unit.MaxHealth {
+			v.report("unit", i, "CurrentHealth %v is greater than MaxHealth %v", unit.CurrentHealth, unit.MaxHealth)
+		}
+		if unit.GeneralId == 0 {
+			continue
+		}
+		if otherUnit, ok := generalIds[unit.GeneralId]; ok {
+			v.report("unit", i, "GeneralId %v is also assigned to unit %v", unit.GeneralId, otherUnit)
+		} else {
+			generalIds[unit.GeneralId] = i
+		}
+	}
+}
+
+func (v *saveValidator) validateLandmines() {
+	for i, landmine := range v.saveOutput.Landmines {
+		v.checkCoordinate("landmine", i, landmine.CoordinateCode)
+		if landmine.Owner != NoTileOwner && landmine.Owner != 0xFFFF && int(landmine.Owner) >= len(v.saveOutput.PlayerData) {
+			v.report("landmine", i, "owned by player %v, but there are only %v players", landmine.Owner, len(v.saveOutput.PlayerData))
+		}
+	}
+}
+
+// ValidateSave checks the structural invariants of a parsed save and returns every
+// issue found. A save with no issues can still be rejected by the game, but one
+// with issues is likely to crash it or the tools in this package.
+func ValidateSave(saveOutput *WC4SaveOutput) []ValidationIssue {
+	v := &saveValidator{saveOutput: saveOutput, issues: make([]ValidationIssue, 0)}
+	v.validateCounts()
+	v.validateTiles()
+	v.validateCities()
+	v.validateUnits()
+	v.validateLandmines()
+	if len(saveOutput.TrailingData) > 0 {
+		v.report("file", -1, "%v bytes after the last section were not parsed", len(saveOutput.TrailingData))
+	}
+	return v.issues
+}
@@ -0,0 +1,65 @@
+package fileio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSaveFixtures(t *testing.T) {
+	for _, fixture := range []string{"conquest.sav", "campaign.sav"} {
+		saveOutput, err := ReadSaveData(readFixture(t, fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if issues := ValidateSave(saveOutput); len(issues) != 0 {
+			t.Errorf("%v: expected no issues, got %v", fixture, issues)
+		}
+	}
+}
+
+func TestValidateSaveIssues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		edit     func(saveOutput *WC4SaveOutput)
+		expected string
+	}{
+		{"unit count", func(s *WC4SaveOutput) { s.SaveHeader.UnitCount = 5 },
+			"units: header UnitCount is 5, but there are 4 records"},
+		{"owner row", func(s *WC4SaveOutput) { s.UnitOwnerData[3] = s.UnitOwnerData[3][:5] },
+			"unit owners: row 3 has 5 tiles, but the map width is 6"},
+		{"owner player", func(s *WC4SaveOutput) { s.UnitOwnerData[2][2] = 3 },
+			"unit owners: tile at row 2, column 2 is owned by player 3, but there are only 3 players"},
+		{"city tile", func(s *WC4SaveOutput) { s.CityTiles[0][5] = 5 },
+			"city tiles: tile at row 0, column 5 belongs to coordinate code 5, where there is no city"},
+		{"landmine coordinate", func(s *WC4SaveOutput) { s.Landmines[0].CoordinateCode = 1000 },
+			"landmine 0: coordinate code 1000 is row 164, column 4, which is outside the 6x5 map"},
+		{"unit tile owner", func(s *WC4SaveOutput) { s.UnitOwnerData[0][3] = NoTileOwner },
+			"unit 0: tile at row 0, column 3 has no owner"},
+		{"unit health", func(s *WC4SaveOutput) { s.Units[2].CurrentHealth = 201 },
+			"unit 2: CurrentHealth 201 is greater than MaxHealth 200"},
+		{"general", func(s *WC4SaveOutput) { s.Units[0].GeneralId = 12 },
+			"unit 1: GeneralId 12 is also assigned to unit 0"},
+		{"landmine owner", func(s *WC4SaveOutput) { s.Landmines[0].Owner = 7 },
+			"landmine 0: owned by player 7, but there are only 3 players"},
+		{"trailing data", func(s *WC4SaveOutput) { s.TrailingData = []byte{1, 2, 3} },
+			"file: 3 bytes after the last section were not parsed"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			saveOutput, err := ReadSaveData(readFixture(t, "conquest.sav"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCase.edit(saveOutput)
+
+			issues := ValidateSave(saveOutput)
+			issueNames := make([]string, 0, len(issues))
+			for _, issue := range issues {
+				issueNames = append(issueNames, issue.String())
+			}
+			if len(issues) != 1 || issues[0].String() != testCase.expected {
+				t.Errorf("expected only %q, got:\n%v", testCase.expected, strings.Join(issueNames, "\n"))
+			}
+		})
+	}
+}
@@ -1,126 +1,218 @@
 package fileio
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
 )
 
-func WriteUint8AtFileOffset(inputFilename string, offset int, value int) {
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
+// writeFileAtomic replaces outputFilename with data without ever leaving a partially
+// written file behind. The data goes to a temp file in the same directory, which is
+// synced and then renamed over the original. An existing file keeps its permissions.
+func writeFileAtomic(outputFilename string, data []byte) (err error) {
+	fileMode := os.FileMode(0644)
+	if fileInfo, statErr := os.Stat(outputFilename); statErr == nil {
+		fileMode = fileInfo.Mode().Perm()
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(outputFilename), "."+filepath.Base(outputFilename)+".*.tmp")
 	if err != nil {
-		log.Fatal("Failed to load save state: ", err)
+		return fmt.Errorf("failed to write save: %w", err)
 	}
+	tempFilename := tempFile.Name()
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFilename)
+		}
+	}()
 
-	if value >= 256 {
-		log.Fatal("Value is too large for uint8")
+	if _, err = tempFile.Write(data); err != nil {
+		return fmt.Errorf("failed to write save: %w", err)
+	}
+	if err = tempFile.Chmod(fileMode); err != nil {
+		return fmt.Errorf("failed to write save: %w", err)
+	}
+	if err = tempFile.Sync(); err != nil {
+		return fmt.Errorf("failed to write save: %w", err)
 	}
-	if _, err := inputFile.WriteAt([]byte{uint8(value)}, int64(offset)); err != nil {
-		log.Fatal("Failed to write uint8 to file:", err)
+	if err = tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write save: %w", err)
 	}
+	if err = os.Rename(tempFilename, outputFilename); err != nil {
+		return fmt.Errorf("failed to write save: %w", err)
+	}
+	syncDir(filepath.Dir(outputFilename))
+	return nil
 }
 
-func WriteUint16AtFileOffset(inputFilename string, offset int, updatedValue int) {
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
+// syncDir flushes a rename to disk. Not every platform can sync a directory, so errors are ignored.
+func syncDir(dirName string) {
+	dir, err := os.Open(dirName)
 	if err != nil {
-		log.Fatal("Failed to load save state: ", err)
+		return
 	}
+	dir.Sync()
+	dir.Close()
+}
 
-	if updatedValue >= 65536 {
-		log.Fatal("Value is too large for uint16")
-	}
-	byteArrUnitType := make([]byte, 2)
-	binary.LittleEndian.PutUint16(byteArrUnitType, uint16(updatedValue))
-	if _, err := inputFile.WriteAt(byteArrUnitType, int64(offset)); err != nil {
-		log.Fatal(err)
+// readSaveImage returns the uncompressed contents of a save file and its LZ4 framing
+func readSaveImage(inputFilename string) ([]byte, *SaveCompression, error) {
+	fileData, err := os.ReadFile(inputFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load save state: %w", err)
 	}
+	return decompressSave(fileData)
 }
 
-func WriteUint32AtFileOffset(inputFilename string, offset int, updatedValue int) {
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
-	if err != nil {
-		log.Fatal("Failed to load save state: ", err)
+// writeSaveImage replaces a save file with saveData, compressed with the same framing it was read with
+func writeSaveImage(outputFilename string, saveData []byte, compression *SaveCompression) error {
+	buffer := &bytes.Buffer{}
+	if err := compressSave(buffer, saveData, compression); err != nil {
+		return err
 	}
+	return writeFileAtomic(outputFilename, buffer.Bytes())
+}
 
-	if updatedValue >= 4294967295 {
-		log.Fatal("Value is too large for uint32")
+func writeSection(w io.Writer, section string, data interface{}) error {
+	if err := binary.Write(w, binary.LittleEndian, data); err != nil {
+		return fmt.Errorf("failed to write %v: %w", section, err)
 	}
-	byteArrUnitType := make([]byte, 4)
-	binary.LittleEndian.PutUint32(byteArrUnitType, uint32(updatedValue))
-	if _, err := inputFile.WriteAt(byteArrUnitType, int64(offset)); err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+func SerializeMapHeaderToBytes(w io.Writer, saveHeader SaveHeader) error {
+	return writeSection(w, "header", saveHeader)
+}
+
+func SerializeCountryDataToBytes(w io.Writer, allPlayerData []CountryData) error {
+	for i := 0; i < len(allPlayerData); i++ {
+		if err := writeSection(w, fmt.Sprintf("country data %v", i), allPlayerData[i]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func WriteAndShiftData(inputFilename string, offsetStartOriginalBlockKey string, offsetEndOriginalBlockKey string, newData []byte) {
-	// Open file to modify
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
-	if err != nil {
-		log.Fatal("Failed to load save state:", err)
+func SerializeUnknownCampaignBlockToBytes(w io.Writer, allCampaignTiles [][]CampaignTileData, mapWidth int) error {
+	for i := 0; i < len(allCampaignTiles); i++ {
+		if len(allCampaignTiles[i]) != mapWidth {
+			return fmt.Errorf("campaign block row %v has %v columns, expected %v", i, len(allCampaignTiles[i]), mapWidth)
+		}
+		if err := writeSection(w, fmt.Sprintf("campaign block row %v", i), allCampaignTiles[i]); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	offsetOriginalBlockStart, ok := fileOffsetMap[offsetStartOriginalBlockKey]
-	if !ok {
-		log.Fatal(fmt.Sprintf("Error: Unable to find start of data block with key %v. Command not run.", offsetStartOriginalBlockKey))
+func SerializeCityTileOwnershipToBytes(w io.Writer, allCityTiles [][]uint16, mapWidth int) error {
+	for i := 0; i < len(allCityTiles); i++ {
+		if len(allCityTiles[i]) != mapWidth {
+			return fmt.Errorf("city tiles row %v has %v columns, expected %v", i, len(allCityTiles[i]), mapWidth)
+		}
+		if err := writeSection(w, fmt.Sprintf("city tiles row %v", i), allCityTiles[i]); err != nil {
+			return err
+		}
 	}
-	offsetOriginalBlockEnd, ok := fileOffsetMap[offsetEndOriginalBlockKey]
-	if !ok {
-		log.Fatal(fmt.Sprintf("Error: Unable to find end of data block with key %v. Command not run.", offsetStartOriginalBlockKey))
+	return nil
+}
+
+func SerializeUnitOwnerDataToBytes(w io.Writer, unitOwnerData [][]byte, mapWidth int) error {
+	for i := 0; i < len(unitOwnerData); i++ {
+		if len(unitOwnerData[i]) != mapWidth {
+			return fmt.Errorf("unit owner row %v has %v columns, expected %v", i, len(unitOwnerData[i]), mapWidth)
+		}
+		if err := writeSection(w, fmt.Sprintf("unit owner row %v", i), unitOwnerData[i]); err != nil {
+			return err
+		}
 	}
-	// Get all data after end of block
-	remainder := GetFileRemainingData(inputFile, offsetOriginalBlockEnd)
+	return nil
+}
 
-	// overwrite block with new data at original block start
-	if _, err := inputFile.WriteAt(newData, int64(offsetOriginalBlockStart)); err != nil {
-		log.Fatal(err)
+func SerializeCityDataToBytes(w io.Writer, allCities []CityData) error {
+	for i := 0; i < len(allCities); i++ {
+		if err := writeSection(w, fmt.Sprintf("city data %v", i), allCities[i]); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// shift remaining data and write after new data instead of original end start
-	if _, err := inputFile.WriteAt(remainder, int64(offsetOriginalBlockStart+len(newData))); err != nil {
-		log.Fatal(err)
+func SerializeUnitDataToBytes(w io.Writer, allUnits []UnitData) error {
+	for i := 0; i < len(allUnits); i++ {
+		if err := writeSection(w, fmt.Sprintf("unit data %v", i), allUnits[i]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func GetFileRemainingData(inputFile *os.File, offset int) []byte {
-	if _, err := inputFile.Seek(int64(offset), 0); err != nil {
-		log.Fatal(err)
+func checkSectionCount(section string, headerCount uint32, actualCount int) error {
+	if int(headerCount) != actualCount {
+		return fmt.Errorf("header expects %v %v, found %v", headerCount, section, actualCount)
 	}
-	remainder, err := ioutil.ReadAll(inputFile)
-	if err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+// WriteSaveFile serializes the whole save in the same order ReadSaveFile reads it and
+// compresses it again if it was read from an LZ4 frame. Reading a save and writing it
+// back without changes produces identical uncompressed data.
+func WriteSaveFile(w io.Writer, saveOutput *WC4SaveOutput) error {
+	buffer := &bytes.Buffer{}
+	if err := serializeSaveFile(buffer, saveOutput); err != nil {
+		return err
 	}
-	return remainder
+	return compressSave(w, buffer.Bytes(), saveOutput.Compression)
 }
 
-func WriteUnitOwnerToFile(inputFilename string, value int, targetX int, targetY int) {
-	inputFile, err := os.OpenFile(inputFilename, os.O_RDWR, 0644)
-	defer inputFile.Close()
+func serializeSaveFile(w io.Writer, saveOutput *WC4SaveOutput) error {
+	for _, section := range saveSections {
+		if section.headerCount == nil {
+			continue
+		}
+		headerCount, actualCount := section.headerCount(saveOutput)
+		if err := checkSectionCount(section.name, headerCount, actualCount); err != nil {
+			return err
+		}
+	}
+	for _, section := range saveSections {
+		if err := section.write(w, saveOutput); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyRoundTrip reads a save file, serializes it again and checks that
+// the output matches the original file byte for byte. Compressed saves are
+// compared after decompression.
+func VerifyRoundTrip(inputFilename string) error {
+	originalData, compression, err := readSaveImage(inputFilename)
 	if err != nil {
-		log.Fatal("Failed to load save state: ", err)
+		return err
 	}
-
-	offsetStartOriginalBlockKey := buildSingleUnitOwnerStartKey(targetX, targetY)
-	offset, ok := fileOffsetMap[offsetStartOriginalBlockKey]
-	if !ok {
-		log.Fatal(fmt.Sprintf("Error: Unable to find start of data block with key %v. Command not run.", offsetStartOriginalBlockKey))
+	saveOutput, err := parseSaveData(originalData, compression)
+	if err != nil {
+		return err
 	}
 
-	if _, err := inputFile.WriteAt([]byte{uint8(value)}, int64(offset)); err != nil {
-		log.Fatal("Failed to write uint8 to file:", err)
+	buffer := &bytes.Buffer{}
+	if err := serializeSaveFile(buffer, saveOutput); err != nil {
+		return err
 	}
-}
+	writtenData := buffer.Bytes()
 
-func WriteAllUnitOwnersToFile(inputFilename string, tileDataOverwrite [][]byte) {
-	byteData := make([]byte, 0)
-	for i := 0; i < len(tileDataOverwrite); i++ {
-		byteData = append(byteData, tileDataOverwrite[i]...)
+	for i := 0; i < len(originalData) && i < len(writtenData); i++ {
+		if originalData[i] != writtenData[i] {
+			return fmt.Errorf("round trip differs at offset %v", i)
+		}
 	}
-
-	WriteAndShiftData(inputFilename, buildUnitOwnerStartKey(), buildUnitOwnerEndKey(), byteData)
+	if len(originalData) != len(writtenData) {
+		return fmt.Errorf("round trip wrote %v bytes, original file has %v bytes", len(writtenData), len(originalData))
+	}
+	return nil
 }
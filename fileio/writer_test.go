@@ -0,0 +1,118 @@
+package fileio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteSaveFileRoundTrip(t *testing.T) {
+	for _, fixture := range []string{"conquest.sav", "campaign.sav", "conquest_lz4.sav"} {
+		t.Run(fixture, func(t *testing.T) {
+			fileData := readFixture(t, fixture)
+			saveOutput, err := ReadSaveData(fileData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buffer := &bytes.Buffer{}
+			if err := WriteSaveFile(buffer, saveOutput); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buffer.Bytes(), fileData) {
+				t.Errorf("written save differs from %v: %v", fixture, CompareSaveData(fileData, buffer.Bytes()))
+			}
+			if err := VerifyRoundTrip(filepath.Join("testdata", fixture)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// dirNames lists the files in a directory, to check that no temporary file is left behind
+func dirNames(t *testing.T, dirName string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dirName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomicReplacesFile(t *testing.T) {
+	dirName := t.TempDir()
+	outputFilename := filepath.Join(dirName, "save.sav")
+	if err := os.WriteFile(outputFilename, []byte("old save"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(outputFilename, []byte("new save")); err != nil {
+		t.Fatal(err)
+	}
+	fileData, err := os.ReadFile(outputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fileData) != "new save" {
+		t.Errorf("expected the new save, got %q", fileData)
+	}
+	fileInfo, err := os.Stat(outputFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("expected the file mode 0600 to be kept, got %v", fileInfo.Mode().Perm())
+	}
+	if names := dirNames(t, dirName); !reflect.DeepEqual(names, []string{"save.sav"}) {
+		t.Errorf("expected only save.sav, got %v", names)
+	}
+}
+
+func TestWriteFileAtomicRemovesTempFileOnError(t *testing.T) {
+	dirName := t.TempDir()
+	// a directory that isn't empty can't be replaced by the rename
+	outputFilename := filepath.Join(dirName, "save.sav")
+	if err := os.MkdirAll(filepath.Join(outputFilename, "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(outputFilename, []byte("new save")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if names := dirNames(t, dirName); !reflect.DeepEqual(names, []string{"save.sav"}) {
+		t.Errorf("expected the temporary file to be removed, got %v", names)
+	}
+}
+
+func TestCommitKeepsCompression(t *testing.T) {
+	session := openFixture(t, "conquest_lz4.sav")
+	if err := session.SetCurrency(0, 0, 777); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileData, err := os.ReadFile(session.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(fileData, readFixture(t, "conquest_lz4.sav")[:4]) {
+		t.Errorf("expected the save to stay in an LZ4 frame, got % x", fileData[:4])
+	}
+	reopened, err := Open(session.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if currency := reopened.Save.PlayerData[0].Currency[0]; currency != 777 {
+		t.Errorf("expected currency 777 after saving, got %v", currency)
+	}
+	if names := dirNames(t, filepath.Dir(session.Path)); !reflect.DeepEqual(names, []string{"conquest_lz4.sav"}) {
+		t.Errorf("expected only conquest_lz4.sav, got %v", names)
+	}
+}
@@ -0,0 +1,197 @@
+// Package gamedata names the numeric IDs stored in World Conqueror 4 saves.
+//
+// The built-in names are read from names.yaml, which is embedded into the
+// editor. Only IDs that have been confirmed against saves are listed there. More
+// names can be loaded from a JSON or YAML file with LoadNames, for example one
+// exported from the game's data files, without changing the code.
+package gamedata
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CityUnitType is the unit type of the unit record that every city has
+const CityUnitType = 39
+
+const (
+	GeneralBadgeCount    = 3
+	GeneralSkillCount    = 5
+	MaxGeneralSkillLevel = 5 // skills are shown as up to five stars
+)
+
+// NameTable maps the IDs of one kind of game object to names
+type NameTable struct {
+	Kind  string
+	names map[int]string
+}
+
+func newNameTable(kind string, names map[int]string) *NameTable {
+	return &NameTable{Kind: kind, names: names}
+}
+
+var (
+	UnitTypes      = newNameTable("unit type", map[int]string{})
+	Countries      = newNameTable("country", map[int]string{})
+	Cities         = newNameTable("city", map[int]string{})
+	Generals       = newNameTable("general", map[int]string{})
+	GeneralTitles  = newNameTable("general title", map[int]string{})
+	GeneralRanks   = newNameTable("general rank", map[int]string{})
+	GeneralBadges  = newNameTable("general badge", map[int]string{})
+	GeneralSkills  = newNameTable("general skill", map[int]string{}) // keyed by the index into GeneralSkillLevels
+	BuildingTypes  = newNameTable("building type", map[int]string{})
+	Wonders        = newNameTable("wonder", map[int]string{})
+	AntiAirWeapons = newNameTable("anti-air weapon", map[int]string{})
+	CityTechs      = newNameTable("city tech", map[int]string{}) // keyed by the index into TechLevels
+)
+
+// tables is keyed by the section names of a names file
+var tables = map[string]*NameTable{
+	"UnitTypes":      UnitTypes,
+	"Countries":      Countries,
+	"Cities":         Cities,
+	"Generals":       Generals,
+	"GeneralTitles":  GeneralTitles,
+	"GeneralRanks":   GeneralRanks,
+	"GeneralBadges":  GeneralBadges,
+	"GeneralSkills":  GeneralSkills,
+	"BuildingTypes":  BuildingTypes,
+	"Wonders":        Wonders,
+	"AntiAirWeapons": AntiAirWeapons,
+	"CityTechs":      CityTechs,
+}
+
+//go:embed names.yaml
+var builtinNames []byte
+
+func init() {
+	if err := addNames(builtinNames, false, "built-in names"); err != nil {
+		panic(err)
+	}
+}
+
+// Name returns the name of an ID, or "" if it has none
+func (t *NameTable) Name(id int) string {
+	return t.names[id]
+}
+
+// Format returns the ID followed by its name in parentheses if it has one
+func (t *NameTable) Format(id int) string {
+	if name, ok := t.names[id]; ok {
+		return fmt.Sprintf("%v (%v)", id, name)
+	}
+	return strconv.Itoa(id)
+}
+
+// Set names an ID, replacing any previous name
+func (t *NameTable) Set(id int, name string) {
+	t.names[id] = name
+}
+
+// IDs returns every named ID in ascending order
+func (t *NameTable) IDs() []int {
+	ids := make([]int, 0, len(t.names))
+	for id := range t.names {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func normalizeName(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// Lookup returns the ID for a number or a name. Names are matched ignoring
+// case, spaces, underscores and dashes, so "heavy-tank" matches "Heavy Tank".
+func (t *NameTable) Lookup(value string) (int, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, nil
+	}
+	normalizedValue := normalizeName(value)
+	for _, id := range t.IDs() {
+		if normalizeName(t.names[id]) == normalizedValue {
+			return id, nil
+		}
+	}
+
+	knownNames := make([]string, 0)
+	for _, id := range t.IDs() {
+		knownNames = append(knownNames, t.names[id])
+	}
+	if len(knownNames) == 0 {
+		return 0, fmt.Errorf("unknown %v %q, no %v names are loaded so give the number instead", t.Kind, value, t.Kind)
+	}
+	return 0, fmt.Errorf("unknown %v %q, expected a number or one of: %v", t.Kind, value, strings.Join(knownNames, ", "))
+}
+
+// Check returns an error if id is not a valid value for a field of the table's kind.
+// The id must be between 0 and maxID, the largest value the field can hold. If the
+// table names any IDs, it must also be one of them or 0, which the editor writes
+// when it clears a field. Tables without names can only check the range.
+func (t *NameTable) Check(id int, maxID int) error {
+	if id < 0 || id > maxID {
+		return fmt.Errorf("%v must be between 0 and %v, got %v", t.Kind, maxID, id)
+	}
+	if id == 0 || len(t.names) == 0 {
+		return nil
+	}
+	if _, ok := t.names[id]; !ok {
+		knownIDs := make([]string, 0, len(t.names))
+		for _, knownID := range t.IDs() {
+			knownIDs = append(knownIDs, t.Format(knownID))
+		}
+		return fmt.Errorf("unknown %v %v, expected 0 or one of: %v", t.Kind, id, strings.Join(knownIDs, ", "))
+	}
+	return nil
+}
+
+// LoadNames adds the names in a JSON or YAML file to the tables. The file has
+// one object per table, keyed by ID:
+//
+//	UnitTypes:
+//	  39: City
+//	Countries:
+//	  10: Germany
+func LoadNames(inputFilename string) error {
+	fileData, err := os.ReadFile(inputFilename)
+	if err != nil {
+		return fmt.Errorf("failed to read names: %w", err)
+	}
+	return addNames(fileData, strings.HasSuffix(inputFilename, ".json"), inputFilename)
+}
+
+func addNames(fileData []byte, isJSON bool, source string) error {
+	var err error
+	namesFile := make(map[string]map[string]string)
+	if isJSON {
+		err = json.Unmarshal(fileData, &namesFile)
+	} else {
+		err = yaml.Unmarshal(fileData, &namesFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse names in %v: %w", source, err)
+	}
+
+	for tableName, names := range namesFile {
+		table, ok := tables[tableName]
+		if !ok {
+			return fmt.Errorf("unknown name table %v in %v", tableName, source)
+		}
+		for idText, name := range names {
+			id, err := strconv.Atoi(idText)
+			if err != nil {
+				return fmt.Errorf("%v in %v: ID %q is not a number", tableName, source, idText)
+			}
+			table.Set(id, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package gamedata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuiltinNames(t *testing.T) {
+	// the embedded names are loaded without a names file
+	for _, value := range []string{"City", "city", "39"} {
+		id, err := UnitTypes.Lookup(value)
+		if err != nil {
+			t.Fatalf("%v: %v", value, err)
+		}
+		if id != CityUnitType {
+			t.Errorf("%v: expected unit type %v, got %v", value, CityUnitType, id)
+		}
+	}
+	if formatted := UnitTypes.Format(CityUnitType); formatted != "39 (City)" {
+		t.Errorf("expected 39 (City), got %v", formatted)
+	}
+
+	// names.yaml lists every table, so it shows where each kind of name goes
+	namesFile := make(map[string]map[string]string)
+	if err := yaml.Unmarshal(builtinNames, &namesFile); err != nil {
+		t.Fatal(err)
+	}
+	for tableName := range tables {
+		if _, ok := namesFile[tableName]; !ok {
+			t.Errorf("names.yaml has no %v table", tableName)
+		}
+	}
+}
+
+func TestLoadNames(t *testing.T) {
+	inputFilename := filepath.Join(t.TempDir(), "names.json")
+	if err := os.WriteFile(inputFilename, []byte(`{"UnitTypes": {"200": "Test Tank"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadNames(inputFilename); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(UnitTypes.names, 200)
+
+	id, err := UnitTypes.Lookup("test-tank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 200 {
+		t.Errorf("expected unit type 200, got %v", id)
+	}
+	if UnitTypes.Name(CityUnitType) != "City" {
+		t.Error("loading names replaced the built-in names")
+	}
+}
+
+func TestNameTableCheck(t *testing.T) {
+	emptyTable := newNameTable("general rank", map[int]string{})
+	for _, id := range []int{0, 7, 255} {
+		if err := emptyTable.Check(id, 255); err != nil {
+			t.Errorf("%v: %v", id, err)
+		}
+	}
+	for _, id := range []int{-1, 256} {
+		if err := emptyTable.Check(id, 255); err == nil {
+			t.Errorf("%v: expected an error", id)
+		}
+	}
+
+	namedTable := newNameTable("general rank", map[int]string{1: "Colonel", 2: "General"})
+	for _, id := range []int{0, 1, 2} {
+		if err := namedTable.Check(id, 255); err != nil {
+			t.Errorf("%v: %v", id, err)
+		}
+	}
+	err := namedTable.Check(3, 255)
+	if err == nil {
+		t.Fatal("expected an error for an ID without a name")
+	}
+	if expected := "unknown general rank 3, expected 0 or one of: 1 (Colonel), 2 (General)"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err)
+	}
+}
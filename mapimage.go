@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+type MapRenderOptions struct {
+	TileSize  int
+	ShowGrid  bool
+	ShowUnits bool
+}
+
+var (
+	gridLineColor = color.RGBA{0, 0, 0, 96}
+	labelColor    = color.RGBA{220, 220, 220, 255}
+)
+
+// RenderMap draws the ownership grid. Tiles holding a unit or city owned by a player use
+// the player's full color, tiles inside a city's territory use a faded color of the city owner.
+func RenderMap(saveOutput *fileio.WC4SaveOutput, options MapRenderOptions) image.Image {
+	mapHeight := len(saveOutput.UnitOwnerData)
+	mapWidth := 0
+	if mapHeight > 0 {
+		mapWidth = len(saveOutput.UnitOwnerData[0])
+	}
+	gameMode := int(saveOutput.SaveHeader.GameMode)
+	tileSize := float64(options.TileSize)
+
+	margin := 0.0
+	if options.ShowGrid {
+		margin = 28
+	}
+	dc := gg.NewContext(int(margin+float64(mapWidth)*tileSize), int(margin+float64(mapHeight)*tileSize))
+	dc.SetColor(color.Black)
+	dc.Clear()
+
+	tileX := func(col int) float64 { return margin + float64(col)*tileSize }
+	tileY := func(row int) float64 { return margin + float64(row)*tileSize }
+
+	for i, colorRow := range fileio.TileColors(saveOutput) {
+		for j, tileColor := range colorRow {
+			dc.SetColor(tileColor)
+			dc.DrawRectangle(tileX(j), tileY(i), tileSize, tileSize)
+			dc.Fill()
+		}
+	}
+
+	for _, city := range saveOutput.Cities {
+		row, col := fileio.ConvertCoordinates(int(city.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+		inset := tileSize * 0.2
+		dc.DrawRectangle(tileX(col)+inset, tileY(row)+inset, tileSize-2*inset, tileSize-2*inset)
+		dc.SetColor(color.White)
+		dc.FillPreserve()
+		dc.SetColor(color.Black)
+		dc.SetLineWidth(2)
+		dc.Stroke()
+	}
+
+	if options.ShowUnits {
+		for _, unit := range saveOutput.Units {
+			row, col := fileio.ConvertCoordinates(int(unit.CoordinateCode), saveOutput.UnitOwnerData, gameMode)
+			centerX := tileX(col) + tileSize/2
+			centerY := tileY(row) + tileSize/2
+			dc.DrawCircle(centerX, centerY, tileSize*0.22)
+			dc.SetColor(fileio.BlendColor(fileio.PlayerColor(saveOutput, fileio.GetTileOwner(saveOutput, row, col)), color.RGBA{0, 0, 0, 255}, 0.6))
+			dc.FillPreserve()
+			dc.SetColor(color.White)
+			dc.SetLineWidth(1)
+			dc.Stroke()
+			if unit.GeneralId > 0 {
+				dc.SetColor(color.RGBA{255, 215, 0, 255})
+				dc.DrawStringAnchored("G", centerX, centerY, 0.5, 0.35)
+			}
+		}
+	}
+
+	if options.ShowGrid {
+		dc.SetColor(gridLineColor)
+		dc.SetLineWidth(1)
+		for i := 0; i <= mapHeight; i++ {
+			dc.DrawLine(margin, tileY(i), tileX(mapWidth), tileY(i))
+		}
+		for j := 0; j <= mapWidth; j++ {
+			dc.DrawLine(tileX(j), margin, tileX(j), tileY(mapHeight))
+		}
+		dc.Stroke()
+
+		// label every tile when there is room, otherwise every fifth tile
+		labelStep := 1
+		if tileSize < 24 {
+			labelStep = 5
+		}
+		dc.SetColor(labelColor)
+		for i := 0; i < mapHeight; i += labelStep {
+			dc.DrawStringAnchored(fmt.Sprint(i), margin/2, tileY(i)+tileSize/2, 0.5, 0.35)
+		}
+		for j := 0; j < mapWidth; j += labelStep {
+			dc.DrawStringAnchored(fmt.Sprint(j), tileX(j)+tileSize/2, margin/2, 0.5, 0.35)
+		}
+	}
+
+	return dc.Image()
+}
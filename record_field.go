@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samuelyuan/WorldConqueror4SaveEditor/fileio"
+)
+
+// recordFieldFlags are the flags shared by the commands that set one field of a record
+type recordFieldFlags struct {
+	index *optionalInt
+	field *string
+	value *string
+}
+
+func addRecordFieldFlags(flags *flag.FlagSet, record interface{}) *recordFieldFlags {
+	fieldNames := make([]string, 0)
+	for _, field := range fileio.GetRecordFields(record) {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	index := &optionalInt{}
+	flags.Var(index, "index", "record index (required)")
+	return &recordFieldFlags{
+		index: index,
+		field: flags.String("field", "", "field name (required), array elements are selected like TechLevels[2] or UnknownColor[0][1]. One of: "+strings.Join(fieldNames, ", ")),
+		value: flags.String("value", "", "new integer value (required)"),
+	}
+}
+
+func (f *recordFieldFlags) parse(recordCount int) (int, string, int64, error) {
+	if !f.index.set {
+		return 0, "", 0, fmt.Errorf("missing required flag -index")
+	}
+	if f.index.value < 0 || f.index.value >= recordCount {
+		return 0, "", 0, fmt.Errorf("-index must be between 0 and %v, got %v", recordCount-1, f.index.value)
+	}
+	if *f.field == "" {
+		return 0, "", 0, fmt.Errorf("missing required flag -field")
+	}
+	value, err := strconv.ParseInt(*f.value, 10, 64)
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("-value must be an integer, got %q", *f.value)
+	}
+	return f.index.value, *f.field, value, nil
+}